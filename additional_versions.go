@@ -0,0 +1,42 @@
+package composer
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+)
+
+var versionLinePattern = regexp.MustCompile(`^v?(\d+)\.(\d+)(?:\.|$)`)
+
+// additionalVersions returns the constraints listed in
+// BP_COMPOSER_ADDITIONAL_VERSIONS, which are separated by commas or
+// whitespace.
+func additionalVersions() []string {
+	return strings.FieldsFunc(os.Getenv("BP_COMPOSER_ADDITIONAL_VERSIONS"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+}
+
+// versionLine returns the major.minor release line of the given version, or
+// an empty string when the version does not start with one.
+func versionLine(version string) string {
+	matches := versionLinePattern.FindStringSubmatch(version)
+	if matches == nil {
+		return ""
+	}
+
+	return fmt.Sprintf("%s.%s", matches[1], matches[2])
+}
+
+// versionedCommand returns the name under which a Composer dependency of the
+// given version is exposed next to the primary command, such as
+// "composer-2.2", or an empty string when the version has no release line.
+func versionedCommand(name, version string) string {
+	line := versionLine(version)
+	if line == "" {
+		return ""
+	}
+
+	return fmt.Sprintf("%s-%s", name, line)
+}
@@ -0,0 +1,59 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// composerBinDir returns the bin-dir Composer installs package binaries into
+// for the application, following COMPOSER_VENDOR_DIR, COMPOSER_BIN_DIR and
+// the config section of composer.json. It returns false when the application
+// has no composer.json.
+func composerBinDir(workingDir string) (string, bool, error) {
+	path := composerJSONPath(workingDir)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return "", false, nil
+		}
+		return "", false, err
+	}
+
+	var composerJSON struct {
+		Config struct {
+			VendorDir string `json:"vendor-dir"`
+			BinDir    string `json:"bin-dir"`
+		} `json:"config"`
+	}
+	err = json.Unmarshal(content, &composerJSON)
+	if err != nil {
+		return "", false, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	vendorDir := composerJSON.Config.VendorDir
+	if value, ok := os.LookupEnv("COMPOSER_VENDOR_DIR"); ok && value != "" {
+		vendorDir = value
+	}
+	if vendorDir == "" {
+		vendorDir = "vendor"
+	}
+
+	binDir := composerJSON.Config.BinDir
+	if value, ok := os.LookupEnv("COMPOSER_BIN_DIR"); ok && value != "" {
+		binDir = value
+	}
+	if binDir == "" {
+		binDir = "{$vendor-dir}/bin"
+	}
+	binDir = strings.ReplaceAll(binDir, "{$vendor-dir}", vendorDir)
+
+	if filepath.IsAbs(binDir) {
+		return filepath.Clean(binDir), true, nil
+	}
+
+	return filepath.Join(workingDir, binDir), true, nil
+}
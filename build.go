@@ -1,16 +1,18 @@
 package composer
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
-	"time"
+	"strings"
 
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/chronos"
 	"github.com/paketo-buildpacks/packit/v2/draft"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/postal"
 	"github.com/paketo-buildpacks/packit/v2/sbom"
-	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 )
 
 // Note that Go 1.18 requires faux 0.21.0 (https://github.com/ryanmoran/faux/releases/tag/v0.21.0)
@@ -27,10 +29,22 @@ type SBOMGenerator interface {
 	GenerateFromDependency(dependency postal.Dependency, dir string) (sbom.SBOM, error)
 }
 
+//go:generate faux --interface BindingResolver --output fakes/binding_resolver.go
+type BindingResolver interface {
+	Resolve(typ, provider, platformDir string) ([]servicebindings.Binding, error)
+}
+
+//go:generate faux --interface Executable --output fakes/executable.go
+type Executable interface {
+	Execute(execution pexec.Execution) error
+}
+
 func Build(
-	logger scribe.Emitter,
+	logger Logger,
 	dependencyManager DependencyManager,
-	sbomGenerator SBOMGenerator) packit.BuildFunc {
+	sbomGenerator SBOMGenerator,
+	bindingResolver BindingResolver,
+	env Executable) packit.BuildFunc {
 	return func(context packit.BuildContext) (packit.BuildResult, error) {
 		logger.Title("%s %s", context.BuildpackInfo.Name, context.BuildpackInfo.Version)
 		logger.Process("Resolving Composer version")
@@ -50,22 +64,160 @@ func Build(
 
 		launch, build := entryResolver.MergeLayerTypes("composer", context.Plan.Entries)
 
-		// version = "" is entirely fine
-		version, _ := entry.Metadata["version"].(string)
+		resolutionMode, err := versionResolutionMode()
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
 
-		dependency, err := dependencyManager.Resolve(
-			filepath.Join(context.CNBPath, "buildpack.toml"),
-			entry.Name,
-			version,
-			context.Stack)
+		settings, err := readWrapperSettings(context.Layers.Path)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
+		processes, err := launchProcesses(context.WorkingDir)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		// Composer must be available at launch to run the scripts
+		if len(processes) > 0 {
+			launch = true
+		}
+
+		buildpackTOMLPath := filepath.Join(context.CNBPath, "buildpack.toml")
+
 		clock := chronos.DefaultClock
 
-		logger.SelectedDependency(entry, dependency, clock.Now())
-		bom := dependencyManager.GenerateBillOfMaterials(dependency)
+		custom, hasCustom, err := findCustomPhar(bindingResolver, context.CNBPath, context.Platform.Path)
+		if err != nil {
+			return packit.BuildResult{}, err
+		}
+
+		var dependency postal.Dependency
+
+		// A custom phar replaces version resolution altogether. Otherwise
+		// BP_COMPOSER_VERSION always wins, whatever the resolution mode.
+		if hasCustom {
+			dependency = custom.Dependency
+
+			logger.Warning("Using custom Composer artifact from %s instead of a buildpack-provided version", custom.Source)
+			logger.Action("Version: %s", dependency.Version)
+			logger.Action("Checksum: %s", dependency.Checksum)
+			logger.Break()
+		} else if versionSource, _ := entry.Metadata["version-source"].(string); resolutionMode == VersionResolutionIntersection && versionSource != "BP_COMPOSER_VERSION" {
+			entries, err := expandVersionKeywords(buildpackTOMLPath, "composer", context.Plan.Entries)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			entry, dependency, err = resolveIntersection(dependencyManager, buildpackTOMLPath, context.Stack, entries)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		} else {
+			entries, err := expandVersionKeywords(buildpackTOMLPath, "composer", []packit.BuildpackPlanEntry{entry})
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			entry = entries[0]
+
+			// version = "" is entirely fine
+			version, _ := entry.Metadata["version"].(string)
+
+			dependency, err = dependencyManager.Resolve(
+				buildpackTOMLPath,
+				entry.Name,
+				version,
+				context.Stack)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		selectedSource, _ := entry.Metadata["version-source"].(string)
+		if hasCustom {
+			selectedSource = custom.Source
+		}
+
+		report := BuildReport{
+			Candidates: reportCandidates(sortedEntries),
+			Selected: ReportSelection{
+				Version:  dependency.Version,
+				Source:   selectedSource,
+				Checksum: dependency.Checksum,
+			},
+		}
+
+		if !hasCustom {
+			logger.SelectedDependency(entry, dependency, clock.Now())
+
+			err = checkEndOfLife(logger, dependency, clock.Now())
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
+
+		primaryLine := versionLine(dependency.Version)
+
+		var additionalDependencies []postal.Dependency
+		if constraints := additionalVersions(); len(constraints) > 0 {
+			logger.Process("Resolving additional Composer versions")
+
+			var entries []packit.BuildpackPlanEntry
+			for _, constraint := range constraints {
+				entries = append(entries, packit.BuildpackPlanEntry{
+					Name: "composer",
+					Metadata: map[string]interface{}{
+						"version":        constraint,
+						"version-source": "BP_COMPOSER_ADDITIONAL_VERSIONS",
+					},
+				})
+			}
+
+			entries, err = expandVersionKeywords(buildpackTOMLPath, "composer", entries)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			lines := map[string]bool{}
+			for i, additionalEntry := range entries {
+				additionalDependency, err := dependencyManager.Resolve(buildpackTOMLPath, "composer", additionalEntry.Metadata["version"].(string), context.Stack)
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+
+				logger.SelectedDependency(additionalEntry, additionalDependency, clock.Now())
+
+				line := versionLine(additionalDependency.Version)
+				switch {
+				case line == "":
+					return packit.BuildResult{}, fmt.Errorf("cannot install Composer %s side by side: version has no major.minor release line", additionalDependency.Version)
+				case line == primaryLine:
+					logger.Subprocess("Composer %s is already provided by the primary installation", line)
+					logger.Break()
+					continue
+				case lines[line]:
+					return packit.BuildResult{}, fmt.Errorf("BP_COMPOSER_ADDITIONAL_VERSIONS selects Composer %s more than once (from %q)", line, constraints[i])
+				}
+				lines[line] = true
+
+				err = checkEndOfLife(logger, additionalDependency, clock.Now())
+				if err != nil {
+					return packit.BuildResult{}, err
+				}
+
+				additionalDependencies = append(additionalDependencies, additionalDependency)
+			}
+		}
+
+		// The BOM records the download URI, which may carry credentials for a
+		// custom phar
+		bomDependency := dependency
+		if hasCustom {
+			bomDependency.URI = redactURI(dependency.URI)
+		}
+
+		bom := dependencyManager.GenerateBillOfMaterials(append([]postal.Dependency{bomDependency}, additionalDependencies...)...)
 
 		var buildMetadata = packit.BuildMetadata{}
 		var launchMetadata = packit.LaunchMetadata{}
@@ -74,87 +226,124 @@ func Build(
 		}
 
 		if launch {
-			launchMetadata = packit.LaunchMetadata{BOM: bom}
+			launchMetadata = packit.LaunchMetadata{BOM: bom, Processes: processes}
 		}
 
-		if cachedChecksum, ok := composerLayer.Metadata["dependency-checksum"].(string); ok && cachedChecksum == dependency.Checksum {
-			logger.Process("Reusing cached layer %s", composerLayer.Path)
-			logger.Break()
-
-			composerLayer.Launch, composerLayer.Build, composerLayer.Cache = launch, build, build
-
-			return packit.BuildResult{
-				Layers: []packit.Layer{
-					composerLayer,
-				},
-				Build:  buildMetadata,
-				Launch: launchMetadata,
-			}, nil
+		// The php-framework entries only describe the application, so they are
+		// left unmet and passed on to the later buildpacks that require them
+		for _, planEntry := range context.Plan.Entries {
+			if planEntry.Name == PHPFramework {
+				buildMetadata.Unmet = []packit.UnmetEntry{{Name: PHPFramework}}
+				break
+			}
 		}
 
-		logger.Process("Executing build process")
-		logger.Subprocess("Installing Composer %s", dependency.Version)
-
-		composerLayer, err = composerLayer.Reset()
-		if err != nil {
-			return packit.BuildResult{}, err
+		contributor := layerContributor{
+			logger:            logger,
+			dependencyManager: dependencyManager,
+			sbomGenerator:     sbomGenerator,
+			env:               env,
+			clock:             clock,
+			context:           context,
+			wrapperSettings:   settings,
+			launch:            launch,
+			build:             build,
 		}
 
-		composerLayer.Launch, composerLayer.Build, composerLayer.Cache = launch, build, build
+		primaryContributor := contributor
+		primaryContributor.custom = hasCustom
 
-		layerBinPath := filepath.Join(composerLayer.Path, "bin")
-		err = os.MkdirAll(layerBinPath, os.ModePerm)
+		command := filepath.Base(dependency.Name)
+		composerLayer, layerReport, err := primaryContributor.contribute(composerLayer, dependency, command)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
+		report.Layers = append(report.Layers, layerReport)
 
-		duration, err := clock.Measure(func() error {
-			return dependencyManager.Deliver(dependency, context.CNBPath, layerBinPath, context.Platform.Path)
-		})
+		binDir, hasComposerJSON, err := composerBinDir(context.WorkingDir)
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
-		logger.Action("Completed in %s", duration.Round(time.Millisecond))
-		logger.Break()
 
-		fullFilename := filepath.Join(layerBinPath, filepath.Base(dependency.Name))
+		// Layers.Get loads the environment of a cached layer from disk, which
+		// holds the bin-dir of an earlier build
+		delete(composerLayer.SharedEnv, "PATH.append")
+		delete(composerLayer.SharedEnv, "PATH.delim")
 
-		logger.Debug.Subprocess("Composer installed at %s", fullFilename)
+		if hasComposerJSON {
+			logger.Process("Adding %s to the PATH", binDir)
+			logger.Break()
 
-		err = os.Chmod(fullFilename, 0755)
-		if err != nil {
-			return packit.BuildResult{}, err
+			composerLayer.SharedEnv.Append("PATH", binDir, string(os.PathListSeparator))
 		}
 
-		logger.GeneratingSBOM(composerLayer.Path)
-		var sbomContent sbom.SBOM
-		duration, err = clock.Measure(func() error {
-			sbomContent, err = sbomGenerator.GenerateFromDependency(dependency, composerLayer.Path)
-			return err
-		})
-		if err != nil {
-			return packit.BuildResult{}, err
+		if len(additionalDependencies) > 0 && primaryLine != "" {
+			versioned := filepath.Join(composerLayer.Path, "bin", versionedCommand(command, dependency.Version))
+			err = os.RemoveAll(versioned)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+
+			err = os.Symlink(command, versioned)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
 		}
 
-		logger.Action("Completed in %s", duration.Round(time.Millisecond))
-		logger.Break()
+		layers := []packit.Layer{composerLayer}
+		for _, additionalDependency := range additionalDependencies {
+			layerName := fmt.Sprintf("composer-%s", versionLine(additionalDependency.Version))
+
+			logger.Process("Providing Composer %s as %s", additionalDependency.Version, versionedCommand(command, additionalDependency.Version))
+			logger.Break()
+
+			additionalLayer, err := context.Layers.Get(layerName)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
 
-		logger.FormattingSBOM(context.BuildpackInfo.SBOMFormats...)
-		composerLayer.SBOM, err = sbomContent.InFormats(context.BuildpackInfo.SBOMFormats...)
+			additionalLayer, layerReport, err := contributor.contribute(additionalLayer, additionalDependency, versionedCommand(command, additionalDependency.Version))
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+			report.Layers = append(report.Layers, layerReport)
+
+			layers = append(layers, additionalLayer)
+		}
+
+		if len(processes) > 0 {
+			logger.Process("Assigning launch processes:")
+			for _, process := range processes {
+				processType := process.Type
+				if process.Default {
+					processType = fmt.Sprintf("%s (default)", processType)
+				}
+				logger.Subprocess("%s: %s %s", processType, process.Command, strings.Join(process.Args, " "))
+			}
+			logger.Break()
+		}
+
+		err = writeReport(filepath.Join(composerLayer.Path, ReportFilename), report.reproducible())
 		if err != nil {
 			return packit.BuildResult{}, err
 		}
 
-		composerLayer.Metadata = map[string]interface{}{
-			"dependency-checksum": dependency.Checksum,
-		}
+		if reportPath, ok := os.LookupEnv("BP_COMPOSER_REPORT_PATH"); ok && reportPath != "" {
+			if !filepath.IsAbs(reportPath) {
+				reportPath = filepath.Join(context.WorkingDir, reportPath)
+			}
 
-		logger.Debug.Subprocess("Composer layer Checksum is %s", dependency.Checksum)
+			logger.Process("Writing build report to %s", reportPath)
+			logger.Break()
+
+			err = writeReport(reportPath, report)
+			if err != nil {
+				return packit.BuildResult{}, err
+			}
+		}
 
 		return packit.BuildResult{
-			Layers: []packit.Layer{
-				composerLayer,
-			},
+			Layers: layers,
 			Build:  buildMetadata,
 			Launch: launchMetadata,
 		}, nil
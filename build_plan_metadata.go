@@ -6,3 +6,17 @@ type BuildPlanMetadata struct {
 	VersionSource string `toml:"version-source"`
 	Version       string `toml:"version"`
 }
+
+// FrameworkMetadata describes a PHP framework found in composer.lock. It is
+// the metadata of the "php-framework" build plan requirement.
+type FrameworkMetadata struct {
+	Name    string `toml:"name"`
+	Package string `toml:"package"`
+	Version string `toml:"version"`
+}
+
+// PHPMetadata is the metadata of the "php" build plan requirement made when
+// composer.json scripts run as launch processes.
+type PHPMetadata struct {
+	Launch bool `toml:"launch"`
+}
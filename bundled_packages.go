@@ -0,0 +1,376 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+const (
+	installedJSONPath = "vendor/composer/installed.json"
+	installedPHPPath  = "vendor/composer/installed.php"
+)
+
+// BundledPackage is a library that is shipped inside composer.phar.
+type BundledPackage struct {
+	Name      string
+	Version   string
+	Type      string
+	Reference string
+	SourceURL string
+	Licenses  []string
+}
+
+// ReadBundledPackages lists the libraries bundled inside the phar archive at
+// path. It reads vendor/composer/installed.json when the archive contains
+// one, and falls back to vendor/composer/installed.php, which is the only
+// installation record that release builds of composer.phar ship with.
+func ReadBundledPackages(path string) ([]BundledPackage, error) {
+	content, err := ReadPharEntry(path, installedJSONPath)
+	if err == nil {
+		return ParseInstalledJSON(content)
+	}
+
+	if !errors.Is(err, ErrPharEntryNotFound) {
+		return nil, err
+	}
+
+	content, err = ReadPharEntry(path, installedPHPPath)
+	if err != nil {
+		return nil, err
+	}
+
+	return ParseInstalledPHP(content)
+}
+
+// ParseInstalledJSON parses a vendor/composer/installed.json file. Both the
+// Composer 1 layout (a plain list of packages) and the Composer 2 layout (an
+// object with a "packages" list) are supported.
+func ParseInstalledJSON(content []byte) ([]BundledPackage, error) {
+	type installedPackage struct {
+		Name          string   `json:"name"`
+		Version       string   `json:"version"`
+		PrettyVersion string   `json:"pretty_version"`
+		Type          string   `json:"type"`
+		License       []string `json:"license"`
+		Source        struct {
+			URL       string `json:"url"`
+			Reference string `json:"reference"`
+		} `json:"source"`
+	}
+
+	var installed struct {
+		Packages []installedPackage `json:"packages"`
+	}
+
+	err := json.Unmarshal(content, &installed)
+	if err != nil {
+		var typeErr *json.UnmarshalTypeError
+		if !errors.As(err, &typeErr) {
+			return nil, fmt.Errorf("failed to parse %s: %w", installedJSONPath, err)
+		}
+
+		err = json.Unmarshal(content, &installed.Packages)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse %s: %w", installedJSONPath, err)
+		}
+	}
+
+	var packages []BundledPackage
+	for _, p := range installed.Packages {
+		version := p.PrettyVersion
+		if version == "" {
+			version = p.Version
+		}
+
+		if p.Name == "" || version == "" {
+			continue
+		}
+
+		packages = append(packages, BundledPackage{
+			Name:      p.Name,
+			Version:   version,
+			Type:      p.Type,
+			Reference: p.Source.Reference,
+			SourceURL: p.Source.URL,
+			Licenses:  p.License,
+		})
+	}
+
+	return packages, nil
+}
+
+// ParseInstalledPHP parses a vendor/composer/installed.php file. Packages
+// that are only provided or replaced by another package, and the root
+// package itself, are left out because nothing is installed for them.
+func ParseInstalledPHP(content []byte) ([]BundledPackage, error) {
+	parser := phpArrayParser{source: string(content)}
+
+	value, err := parser.parseFile()
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", installedPHPPath, err)
+	}
+
+	installed, ok := value.(map[string]interface{})
+	if !ok {
+		return nil, fmt.Errorf("failed to parse %s: expected an array", installedPHPPath)
+	}
+
+	var rootName string
+	if root, ok := installed["root"].(map[string]interface{}); ok {
+		rootName, _ = root["name"].(string)
+	}
+
+	versions, _ := installed["versions"].(map[string]interface{})
+
+	var names []string
+	for name := range versions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var packages []BundledPackage
+	for _, name := range names {
+		if name == rootName {
+			continue
+		}
+
+		entry, ok := versions[name].(map[string]interface{})
+		if !ok {
+			continue
+		}
+
+		version, _ := entry["pretty_version"].(string)
+		if version == "" {
+			continue
+		}
+
+		packageType, _ := entry["type"].(string)
+		reference, _ := entry["reference"].(string)
+
+		packages = append(packages, BundledPackage{
+			Name:      name,
+			Version:   version,
+			Type:      packageType,
+			Reference: reference,
+		})
+	}
+
+	return packages, nil
+}
+
+// phpArrayParser understands the subset of PHP that Composer writes to
+// installed.php: a single "return" of nested array literals holding strings,
+// numbers, booleans, null and __DIR__ based path concatenations.
+type phpArrayParser struct {
+	source string
+	offset int
+}
+
+func (p *phpArrayParser) parseFile() (interface{}, error) {
+	p.skipWhitespace()
+	if !p.consume("<?php") {
+		return nil, p.errorf("expected <?php")
+	}
+
+	p.skipWhitespace()
+	if !p.consumeKeyword("return") {
+		return nil, p.errorf("expected return statement")
+	}
+
+	value, err := p.parseExpression()
+	if err != nil {
+		return nil, err
+	}
+
+	p.skipWhitespace()
+	if !p.consume(";") {
+		return nil, p.errorf("expected ;")
+	}
+
+	return value, nil
+}
+
+func (p *phpArrayParser) parseExpression() (interface{}, error) {
+	value, err := p.parseTerm()
+	if err != nil {
+		return nil, err
+	}
+
+	for {
+		p.skipWhitespace()
+		if !p.consume(".") {
+			return value, nil
+		}
+
+		next, err := p.parseTerm()
+		if err != nil {
+			return nil, err
+		}
+
+		value = fmt.Sprint(value) + fmt.Sprint(next)
+	}
+}
+
+func (p *phpArrayParser) parseTerm() (interface{}, error) {
+	p.skipWhitespace()
+	if p.offset >= len(p.source) {
+		return nil, p.errorf("unexpected end of input")
+	}
+
+	switch c := p.source[p.offset]; {
+	case c == '\'':
+		return p.parseString()
+	case c == '[':
+		p.offset++
+		return p.parseArrayItems("]")
+	case c == '-' || unicode.IsDigit(rune(c)):
+		return p.parseNumber()
+	}
+
+	word := p.parseWord()
+	switch strings.ToLower(word) {
+	case "array":
+		p.skipWhitespace()
+		if !p.consume("(") {
+			return nil, p.errorf("expected ( after array")
+		}
+		return p.parseArrayItems(")")
+	case "true":
+		return true, nil
+	case "false":
+		return false, nil
+	case "null":
+		return nil, nil
+	case "__dir__":
+		return "", nil
+	case "":
+		return nil, p.errorf("unexpected character %q", p.source[p.offset])
+	default:
+		return nil, p.errorf("unsupported token %q", word)
+	}
+}
+
+func (p *phpArrayParser) parseArrayItems(closing string) (interface{}, error) {
+	items := map[string]interface{}{}
+	next := 0
+
+	for {
+		p.skipWhitespace()
+		if p.consume(closing) {
+			return items, nil
+		}
+
+		value, err := p.parseExpression()
+		if err != nil {
+			return nil, err
+		}
+
+		key := strconv.Itoa(next)
+		p.skipWhitespace()
+		if p.consume("=>") {
+			key = fmt.Sprint(value)
+			value, err = p.parseExpression()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		if index, err := strconv.Atoi(key); err == nil && index >= next {
+			next = index + 1
+		}
+		items[key] = value
+
+		p.skipWhitespace()
+		if p.consume(closing) {
+			return items, nil
+		}
+
+		if !p.consume(",") {
+			return nil, p.errorf("expected , or %s", closing)
+		}
+	}
+}
+
+func (p *phpArrayParser) parseString() (interface{}, error) {
+	p.offset++
+
+	var builder strings.Builder
+	for p.offset < len(p.source) {
+		c := p.source[p.offset]
+		switch {
+		case c == '\'':
+			p.offset++
+			return builder.String(), nil
+		case c == '\\' && p.offset+1 < len(p.source) && (p.source[p.offset+1] == '\'' || p.source[p.offset+1] == '\\'):
+			builder.WriteByte(p.source[p.offset+1])
+			p.offset += 2
+		default:
+			builder.WriteByte(c)
+			p.offset++
+		}
+	}
+
+	return nil, p.errorf("unterminated string")
+}
+
+func (p *phpArrayParser) parseNumber() (interface{}, error) {
+	start := p.offset
+	p.offset++
+	for p.offset < len(p.source) && (unicode.IsDigit(rune(p.source[p.offset])) || p.source[p.offset] == '.') {
+		p.offset++
+	}
+
+	return p.source[start:p.offset], nil
+}
+
+func (p *phpArrayParser) parseWord() string {
+	start := p.offset
+	for p.offset < len(p.source) {
+		c := rune(p.source[p.offset])
+		if !unicode.IsLetter(c) && !unicode.IsDigit(c) && c != '_' {
+			break
+		}
+		p.offset++
+	}
+
+	return p.source[start:p.offset]
+}
+
+func (p *phpArrayParser) consume(token string) bool {
+	if strings.HasPrefix(p.source[p.offset:], token) {
+		p.offset += len(token)
+		return true
+	}
+
+	return false
+}
+
+func (p *phpArrayParser) consumeKeyword(keyword string) bool {
+	start := p.offset
+	if strings.EqualFold(p.parseWord(), keyword) {
+		return true
+	}
+
+	p.offset = start
+	return false
+}
+
+func (p *phpArrayParser) skipWhitespace() {
+	for p.offset < len(p.source) {
+		switch p.source[p.offset] {
+		case ' ', '\t', '\r', '\n':
+			p.offset++
+		default:
+			return
+		}
+	}
+}
+
+func (p *phpArrayParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("offset %d: %s", p.offset, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,66 @@
+package composer
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+)
+
+// verifyCachedComposer checks that the Composer binary in a cached layer is
+// still present and still hashes to the checksum recorded for it. It returns
+// a description of the problem, or an empty string when the binary can be
+// reused.
+func verifyCachedComposer(path, checksum string) (string, error) {
+	exists, err := fs.Exists(path)
+	if err != nil {
+		return "", err
+	}
+
+	if !exists {
+		return fmt.Sprintf("%s is missing", path), nil
+	}
+
+	expected := cargo.Checksum(checksum)
+	if !strings.EqualFold(expected.Algorithm(), "sha256") {
+		return fmt.Sprintf("cannot verify %s checksum of %s", expected.Algorithm(), path), nil
+	}
+
+	sum, err := fs.NewChecksumCalculator().Sum(path)
+	if err != nil {
+		return "", err
+	}
+
+	if sum != expected.Hash() {
+		return fmt.Sprintf("%s does not match checksum %s", path, checksum), nil
+	}
+
+	return "", nil
+}
+
+// missingSBOMFormats returns the media types, out of the given ones, for which
+// the layer has no SBOM file in the layers directory.
+func missingSBOMFormats(layersPath, layerName string, mediaTypes []string) ([]string, error) {
+	var missing []string
+	for _, mediaType := range mediaTypes {
+		base, _, _ := strings.Cut(mediaType, ";")
+		extension := sbom.Format(strings.TrimSpace(base)).Extension()
+		if extension == "" {
+			continue
+		}
+
+		exists, err := fs.Exists(filepath.Join(layersPath, fmt.Sprintf("%s.sbom.%s", layerName, extension)))
+		if err != nil {
+			return nil, err
+		}
+
+		if !exists {
+			missing = append(missing, mediaType)
+		}
+	}
+
+	return missing, nil
+}
@@ -0,0 +1,131 @@
+package composer
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// LatestChannel is the channel every dependency implicitly belongs to when no
+// dependency in buildpack.toml lists it explicitly.
+const LatestChannel = "latest"
+
+// versionKeywordPattern matches version requests that name a channel rather
+// than a semver constraint, such as "lts", "stable" or "2.2-lts".
+var versionKeywordPattern = regexp.MustCompile(`^(?:[a-z][a-z0-9-]*|\d+\.\d+-[a-z][a-z0-9-]*)$`)
+
+var prefixedVersionPattern = regexp.MustCompile(`^v\d`)
+
+// isVersionKeyword reports whether the requested version names a channel.
+// Requests that are also valid semver constraints, like "v2" or "x", are not
+// keywords.
+func isVersionKeyword(version string) bool {
+	version = strings.ToLower(version)
+	if version == "default" || version == "x" || prefixedVersionPattern.MatchString(version) {
+		return false
+	}
+
+	return versionKeywordPattern.MatchString(version)
+}
+
+// versionChannels maps channel names to the versions of the dependencies in
+// buildpack.toml that belong to them, as listed in each dependency's
+// "channels" metadata.
+type versionChannels map[string][]string
+
+func parseVersionChannels(buildpackTOMLPath, id string) (versionChannels, error) {
+	var config struct {
+		Metadata struct {
+			Dependencies []struct {
+				ID       string   `toml:"id"`
+				Version  string   `toml:"version"`
+				Channels []string `toml:"channels"`
+			} `toml:"dependencies"`
+		} `toml:"metadata"`
+	}
+
+	_, err := toml.DecodeFile(buildpackTOMLPath, &config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse buildpack.toml: %w", err)
+	}
+
+	channels := versionChannels{}
+	for _, dependency := range config.Metadata.Dependencies {
+		if dependency.ID != id {
+			continue
+		}
+
+		for _, channel := range dependency.Channels {
+			channel = strings.ToLower(channel)
+			channels[channel] = appendUnique(channels[channel], dependency.Version)
+		}
+	}
+
+	return channels, nil
+}
+
+// expandVersionKeywords replaces channel names in the version requested by
+// each entry with a constraint matching the versions in that channel. The
+// version-source of an expanded entry records the channel, so that the
+// selected dependency is logged with both the keyword and the version.
+func expandVersionKeywords(buildpackTOMLPath, id string, entries []packit.BuildpackPlanEntry) ([]packit.BuildpackPlanEntry, error) {
+	var channels versionChannels
+
+	var expanded []packit.BuildpackPlanEntry
+	for _, entry := range entries {
+		version, _ := entry.Metadata["version"].(string)
+		if !isVersionKeyword(version) {
+			expanded = append(expanded, entry)
+			continue
+		}
+
+		if channels == nil {
+			var err error
+			channels, err = parseVersionChannels(buildpackTOMLPath, id)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		keyword := strings.ToLower(version)
+
+		var constraint string
+		switch versions, ok := channels[keyword]; {
+		case ok:
+			constraint = strings.Join(versions, " || ")
+		case keyword == LatestChannel:
+			constraint = "*"
+		default:
+			return nil, fmt.Errorf("unknown Composer version keyword %q: no dependency in buildpack.toml belongs to that channel", version)
+		}
+
+		source, ok := entry.Metadata["version-source"].(string)
+		if !ok {
+			source = "<unknown>"
+		}
+
+		metadata := map[string]interface{}{}
+		for key, value := range entry.Metadata {
+			metadata[key] = value
+		}
+		metadata["version"] = constraint
+		metadata["version-source"] = fmt.Sprintf("%s, channel %s", source, keyword)
+
+		expanded = append(expanded, packit.BuildpackPlanEntry{Name: entry.Name, Metadata: metadata})
+	}
+
+	return expanded, nil
+}
+
+func appendUnique(values []string, value string) []string {
+	for _, v := range values {
+		if v == value {
+			return values
+		}
+	}
+
+	return append(values, value)
+}
@@ -0,0 +1,158 @@
+package composer
+
+import (
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
+)
+
+// CustomPharBindingType is the type of the binding that provides a custom
+// composer.phar. The binding must contain a "composer.phar" entry and may
+// contain "sha256" and "version" entries.
+const CustomPharBindingType = "composer-phar"
+
+// CustomPharVersion is the version recorded for a custom composer.phar when
+// none is given.
+const CustomPharVersion = "custom"
+
+var sha256Pattern = regexp.MustCompile(`^[0-9a-f]{64}$`)
+
+// customPhar describes a composer.phar provided by the user rather than
+// resolved from buildpack.toml.
+type customPhar struct {
+	Source     string
+	Dependency postal.Dependency
+}
+
+// findCustomPhar returns the custom composer.phar requested through
+// BP_COMPOSER_PHAR_URI or a composer-phar binding, if any.
+func findCustomPhar(bindingResolver BindingResolver, cnbPath, platformPath string) (customPhar, bool, error) {
+	bindings, err := bindingResolver.Resolve(CustomPharBindingType, "", platformPath)
+	if err != nil {
+		return customPhar{}, false, fmt.Errorf("failed to resolve %s bindings: %w", CustomPharBindingType, err)
+	}
+
+	uri, hasURI := os.LookupEnv("BP_COMPOSER_PHAR_URI")
+	hasURI = hasURI && uri != ""
+
+	switch {
+	case hasURI && len(bindings) > 0:
+		return customPhar{}, false, fmt.Errorf("both BP_COMPOSER_PHAR_URI and a %s binding are set: use only one", CustomPharBindingType)
+	case len(bindings) > 1:
+		return customPhar{}, false, fmt.Errorf("found %d %s bindings but expected at most 1", len(bindings), CustomPharBindingType)
+	case hasURI:
+		checksum := strings.ToLower(os.Getenv("BP_COMPOSER_PHAR_SHA256"))
+		if checksum == "" {
+			return customPhar{}, false, fmt.Errorf("BP_COMPOSER_PHAR_SHA256 is required when BP_COMPOSER_PHAR_URI is set")
+		}
+
+		if !sha256Pattern.MatchString(checksum) {
+			return customPhar{}, false, fmt.Errorf("invalid value for BP_COMPOSER_PHAR_SHA256 %q: must be a hex encoded SHA-256 checksum", checksum)
+		}
+
+		version := os.Getenv("BP_COMPOSER_PHAR_VERSION")
+
+		return customPhar{
+			Source:     fmt.Sprintf("BP_COMPOSER_PHAR_URI (%s)", redactURI(uri)),
+			Dependency: customPharDependency(uri, checksum, version),
+		}, true, nil
+	case len(bindings) == 1:
+		return customPharFromBinding(bindings[0], cnbPath)
+	}
+
+	return customPhar{}, false, nil
+}
+
+func customPharFromBinding(binding servicebindings.Binding, cnbPath string) (customPhar, bool, error) {
+	if _, ok := binding.Entries["composer.phar"]; !ok {
+		return customPhar{}, false, fmt.Errorf("%s binding %q has no composer.phar entry", CustomPharBindingType, binding.Name)
+	}
+
+	pharPath := filepath.Join(binding.Path, "composer.phar")
+
+	var checksum string
+	if entry, ok := binding.Entries["sha256"]; ok {
+		value, err := entry.ReadString()
+		if err != nil {
+			return customPhar{}, false, err
+		}
+
+		checksum = strings.ToLower(strings.TrimSpace(value))
+		if !sha256Pattern.MatchString(checksum) {
+			return customPhar{}, false, fmt.Errorf("invalid sha256 entry in %s binding %q: must be a hex encoded SHA-256 checksum", CustomPharBindingType, binding.Name)
+		}
+	} else {
+		var err error
+		checksum, err = fs.NewChecksumCalculator().Sum(pharPath)
+		if err != nil {
+			return customPhar{}, false, err
+		}
+	}
+
+	var version string
+	if entry, ok := binding.Entries["version"]; ok {
+		value, err := entry.ReadString()
+		if err != nil {
+			return customPhar{}, false, err
+		}
+		version = strings.TrimSpace(value)
+	}
+
+	// postal resolves file:// URIs relative to the buildpack directory
+	relativePath, err := filepath.Rel(cnbPath, pharPath)
+	if err != nil {
+		return customPhar{}, false, err
+	}
+
+	return customPhar{
+		Source:     fmt.Sprintf("binding %s (%s)", binding.Name, pharPath),
+		Dependency: customPharDependency(fmt.Sprintf("file://%s", filepath.ToSlash(relativePath)), checksum, version),
+	}, true, nil
+}
+
+func customPharDependency(uri, checksum, version string) postal.Dependency {
+	if version == "" {
+		version = CustomPharVersion
+	}
+
+	return postal.Dependency{
+		ID:       "composer",
+		Name:     "composer",
+		Version:  version,
+		URI:      uri,
+		Source:   redactURI(uri),
+		Checksum: fmt.Sprintf("sha256:%s", checksum),
+		CPEs:     []string{fmt.Sprintf("cpe:2.3:a:getcomposer:composer:%s:*:*:*:*:*:*:*", version)},
+		PURL:     fmt.Sprintf("pkg:generic/composer@%s?checksum=%s", version, checksum),
+		Licenses: []string{"MIT"},
+	}
+}
+
+// redactURI hides the credentials and query of the given URI, so that tokens
+// embedded in BP_COMPOSER_PHAR_URI are neither logged nor recorded in the
+// image. Only URI should be used to download the artifact.
+func redactURI(uri string) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return "(unparseable URI)"
+	}
+
+	if u.User != nil {
+		u.User = url.User("redacted")
+	}
+
+	if u.RawQuery != "" {
+		u.RawQuery = "redacted"
+	}
+	u.Fragment = ""
+	u.RawFragment = ""
+
+	return u.String()
+}
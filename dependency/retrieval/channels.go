@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// composerVersionsURL lists the release channels of Composer along with the
+// newest version in each.
+const composerVersionsURL = "https://getcomposer.org/versions"
+
+// ltsLinePattern matches the channels upstream keeps for a long-term support
+// line, such as "2.2", as opposed to "stable", "preview" or a major version.
+var ltsLinePattern = regexp.MustCompile(`^\d+\.\d+$`)
+
+// FetchLTSLines returns the major.minor lines that upstream maintains as
+// long-term support, according to the channels listed at url.
+func FetchLTSLines(url string) ([]string, error) {
+	response, err := http.DefaultClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("could not get Composer channels: %w", err)
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not get Composer channels: %s returned %s", url, response.Status)
+	}
+
+	var channels map[string]json.RawMessage
+	err = json.NewDecoder(response.Body).Decode(&channels)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse Composer channels: %w", err)
+	}
+
+	var lines []string
+	for channel := range channels {
+		if ltsLinePattern.MatchString(channel) {
+			lines = append(lines, channel)
+		}
+	}
+	sort.Strings(lines)
+
+	return lines, nil
+}
+
+// VersionChannels returns the channels BP_COMPOSER_VERSION can select the
+// given version by: "preview" for pre-releases, and otherwise "stable" plus
+// "lts" and "<line>-lts" when the version is on a long-term support line.
+func VersionChannels(version string, ltsLines []string) ([]string, error) {
+	v, err := semver.NewVersion(version)
+	if err != nil {
+		return nil, fmt.Errorf("invalid version %q: %w", version, err)
+	}
+
+	if v.Prerelease() != "" {
+		return []string{"preview"}, nil
+	}
+
+	channels := []string{"stable"}
+
+	line := fmt.Sprintf("%d.%d", v.Major(), v.Minor())
+	for _, ltsLine := range ltsLines {
+		if ltsLine == line {
+			channels = append(channels, "lts", fmt.Sprintf("%s-lts", line))
+		}
+	}
+
+	return channels, nil
+}
+
+var (
+	tableHeaderPattern = regexp.MustCompile(`^\s*\[`)
+	keyValuePattern    = regexp.MustCompile(`^(\s*)([A-Za-z0-9_-]+)\s*=\s*(.*)$`)
+)
+
+// WriteChannels sets the "channels" metadata of every dependency in the
+// given buildpack.toml content. The dependency update rewrites buildpack.toml
+// without keys it does not know, so the channels are written again after it.
+// The key is inserted where the sorted keys of the update put it, so the rest
+// of the file is left untouched.
+func WriteChannels(content string, ltsLines []string) (string, error) {
+	lines := strings.Split(content, "\n")
+
+	var output []string
+	for i := 0; i < len(lines); i++ {
+		output = append(output, lines[i])
+		if strings.TrimSpace(lines[i]) != "[[metadata.dependencies]]" {
+			continue
+		}
+
+		var block []string
+		for i+1 < len(lines) && !tableHeaderPattern.MatchString(lines[i+1]) {
+			i++
+			block = append(block, lines[i])
+		}
+
+		block, err := writeDependencyChannels(block, ltsLines)
+		if err != nil {
+			return "", err
+		}
+		output = append(output, block...)
+	}
+
+	return strings.Join(output, "\n"), nil
+}
+
+func writeDependencyChannels(block []string, ltsLines []string) ([]string, error) {
+	var version, indent string
+	var keys []string
+	for _, line := range block {
+		matches := keyValuePattern.FindStringSubmatch(line)
+		if matches == nil {
+			keys = append(keys, "")
+			continue
+		}
+
+		keys = append(keys, matches[2])
+		if indent == "" {
+			indent = matches[1]
+		}
+
+		if matches[2] == "version" {
+			err := json.Unmarshal([]byte(matches[3]), &version)
+			if err != nil {
+				return nil, fmt.Errorf("invalid dependency version %s: %w", matches[3], err)
+			}
+		}
+	}
+
+	if version == "" {
+		return block, nil
+	}
+
+	channels, err := VersionChannels(version, ltsLines)
+	if err != nil {
+		return nil, err
+	}
+
+	quoted, err := json.Marshal(channels)
+	if err != nil {
+		return nil, err
+	}
+	channelsLine := fmt.Sprintf("%schannels = %s", indent, strings.ReplaceAll(string(quoted), `","`, `", "`))
+
+	var updated []string
+	inserted := false
+	for i, line := range block {
+		if keys[i] == "channels" {
+			continue
+		}
+
+		if !inserted && keys[i] != "" && keys[i] > "channels" {
+			updated = append(updated, channelsLine)
+			inserted = true
+		}
+		updated = append(updated, line)
+	}
+
+	if !inserted {
+		// Keep the blank lines that separate the tables after the block
+		end := len(updated)
+		for end > 0 && strings.TrimSpace(updated[end-1]) == "" {
+			end--
+		}
+		updated = append(updated[:end], append([]string{channelsLine}, updated[end:]...)...)
+	}
+
+	return updated, nil
+}
+
+// RunChannels implements the channels subcommand and returns its exit code.
+func RunChannels(args []string, stdout io.Writer) int {
+	var buildpackTOMLPath, versionsURL string
+
+	flags := flag.NewFlagSet("channels", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	flags.StringVar(&buildpackTOMLPath, "buildpack_toml_path", "", "path to the buildpack.toml file")
+	flags.StringVar(&versionsURL, "versions_url", composerVersionsURL, "URL of the Composer release channels")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if buildpackTOMLPath == "" {
+		fmt.Fprintln(stdout, "channels requires --buildpack_toml_path")
+		return 2
+	}
+
+	ltsLines, err := FetchLTSLines(versionsURL)
+	if err != nil {
+		fmt.Fprintln(stdout, err)
+		return 1
+	}
+
+	content, err := os.ReadFile(buildpackTOMLPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not read %s: %s\n", buildpackTOMLPath, err)
+		return 1
+	}
+
+	updated, err := WriteChannels(string(content), ltsLines)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not write channels: %s\n", err)
+		return 1
+	}
+
+	err = os.WriteFile(buildpackTOMLPath, []byte(updated), 0644)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not write %s: %s\n", buildpackTOMLPath, err)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "Updated the channels in %s (long-term support lines: %s)\n", buildpackTOMLPath, strings.Join(ltsLines, ", "))
+	return 0
+}
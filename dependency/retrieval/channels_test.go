@@ -0,0 +1,155 @@
+package main_test
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/paketo-buildpacks/pipenv/retrieval"
+)
+
+func testChannels(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		server *httptest.Server
+	)
+
+	it.Before(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/versions" {
+				_, _ = w.Write([]byte(`{
+					"stable": [{"path": "/download/2.10.2/composer.phar", "version": "2.10.2", "min-php": 70205}],
+					"preview": [{"path": "/download/2.11.0-RC1/composer.phar", "version": "2.11.0-RC1", "min-php": 70205}],
+					"1": [{"path": "/download/1.10.27/composer.phar", "version": "1.10.27", "min-php": 50300}],
+					"2": [{"path": "/download/2.10.2/composer.phar", "version": "2.10.2", "min-php": 70205}],
+					"2.2": [{"path": "/download/2.2.25/composer.phar", "version": "2.2.25", "min-php": 50300}]
+				}`))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("FetchLTSLines", func() {
+		it("returns the major.minor channels", func() {
+			lines, err := main.FetchLTSLines(server.URL + "/versions")
+			Expect(err).NotTo(HaveOccurred())
+			Expect(lines).To(Equal([]string{"2.2"}))
+		})
+
+		context("failure cases", func() {
+			context("when the channels cannot be fetched", func() {
+				it("returns an error", func() {
+					_, err := main.FetchLTSLines(server.URL + "/missing")
+					Expect(err).To(MatchError(ContainSubstring("returned 404 Not Found")))
+				})
+			})
+		})
+	})
+
+	context("VersionChannels", func() {
+		it("puts versions on a long-term support line in the lts channels", func() {
+			channels, err := main.VersionChannels("2.2.25", []string{"2.2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(channels).To(Equal([]string{"stable", "lts", "2.2-lts"}))
+		})
+
+		it("puts other versions in the stable channel", func() {
+			channels, err := main.VersionChannels("2.10.2", []string{"2.2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(channels).To(Equal([]string{"stable"}))
+		})
+
+		it("puts pre-releases in the preview channel", func() {
+			channels, err := main.VersionChannels("2.11.0-RC1", []string{"2.2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(channels).To(Equal([]string{"preview"}))
+		})
+	})
+
+	context("WriteChannels", func() {
+		it("sets the channels of every dependency", func() {
+			content, err := main.WriteChannels(`[metadata]
+  [[metadata.dependencies]]
+    channels = ["stable"]
+    checksum = "sha256:aaa"
+    id = "composer"
+    version = "2.2.25"
+
+  [[metadata.dependencies]]
+    checksum = "sha256:bbb"
+    id = "composer"
+    version = "2.10.2"
+
+  [[metadata.dependency-constraints]]
+    constraint = "2.*"
+    id = "composer"
+    patches = 2
+`, []string{"2.2"})
+			Expect(err).NotTo(HaveOccurred())
+			Expect(content).To(Equal(`[metadata]
+  [[metadata.dependencies]]
+    channels = ["stable", "lts", "2.2-lts"]
+    checksum = "sha256:aaa"
+    id = "composer"
+    version = "2.2.25"
+
+  [[metadata.dependencies]]
+    channels = ["stable"]
+    checksum = "sha256:bbb"
+    id = "composer"
+    version = "2.10.2"
+
+  [[metadata.dependency-constraints]]
+    constraint = "2.*"
+    id = "composer"
+    patches = 2
+`))
+		})
+
+		context("failure cases", func() {
+			context("when a version is not valid semver", func() {
+				it("returns an error", func() {
+					_, err := main.WriteChannels(`[[metadata.dependencies]]
+    version = "latest"
+`, nil)
+					Expect(err).To(MatchError(ContainSubstring(`invalid version "latest"`)))
+				})
+			})
+		})
+	})
+
+	context("RunChannels", func() {
+		it("writes the channels into the given buildpack.toml", func() {
+			buildpackTOMLPath := filepath.Join(t.TempDir(), "buildpack.toml")
+			Expect(os.WriteFile(buildpackTOMLPath, []byte(`[[metadata.dependencies]]
+    id = "composer"
+    version = "2.2.25"
+`), 0600)).To(Succeed())
+
+			buffer := bytes.NewBuffer(nil)
+			code := main.RunChannels([]string{"--buildpack_toml_path", buildpackTOMLPath, "--versions_url", server.URL + "/versions"}, buffer)
+			Expect(code).To(Equal(0), buffer.String())
+
+			content, err := os.ReadFile(buildpackTOMLPath)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal(`[[metadata.dependencies]]
+    channels = ["stable", "lts", "2.2-lts"]
+    id = "composer"
+    version = "2.2.25"
+`))
+		})
+	})
+}
@@ -0,0 +1,358 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+// DependencyChange records a field that differs between the buildpack.toml
+// entry and the retrieved entry for the same dependency version.
+type DependencyChange struct {
+	ID      string
+	Version string
+	Field   string
+	Old     string
+	New     string
+}
+
+// DroppedDependency is a dependency that would be removed from
+// buildpack.toml, along with the reason it would be removed.
+type DroppedDependency struct {
+	cargo.ConfigMetadataDependency
+	Reason string
+}
+
+// MetadataDiff describes how applying a set of retrieved dependencies to a
+// buildpack.toml would change its [[metadata.dependencies]].
+type MetadataDiff struct {
+	Added   []cargo.ConfigMetadataDependency
+	Dropped []DroppedDependency
+	Changed []DependencyChange
+
+	// RemovedDefaults maps a dependency id to the version that is currently
+	// selected by [metadata.default-versions] but would be dropped.
+	RemovedDefaults map[string]string
+}
+
+// Empty returns true when the update would not change buildpack.toml.
+func (d MetadataDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Dropped) == 0 && len(d.Changed) == 0
+}
+
+// DiffMetadata merges the retrieved dependencies into the dependencies of
+// the given buildpack.toml config, applies the dependency-constraints and
+// their patches limits, and reports the resulting changes.
+func DiffMetadata(config cargo.Config, retrieved []cargo.ConfigMetadataDependency) (MetadataDiff, error) {
+	diff := MetadataDiff{RemovedDefaults: map[string]string{}}
+
+	existing := map[string]cargo.ConfigMetadataDependency{}
+	for _, dependency := range config.Metadata.Dependencies {
+		existing[dependencyKey(dependency)] = dependency
+	}
+
+	merged := map[string]cargo.ConfigMetadataDependency{}
+	for key, dependency := range existing {
+		merged[key] = dependency
+	}
+
+	for _, dependency := range retrieved {
+		key := dependencyKey(dependency)
+		if old, ok := existing[key]; ok {
+			diff.Changed = append(diff.Changed, compareDependencies(old, dependency)...)
+		}
+		merged[key] = dependency
+	}
+
+	kept, matched, err := applyConstraints(merged, config.Metadata.DependencyConstraints)
+	if err != nil {
+		return MetadataDiff{}, err
+	}
+
+	for key, dependency := range merged {
+		_, wasPresent := existing[key]
+		_, isKept := kept[key]
+
+		switch {
+		case !wasPresent && isKept:
+			diff.Added = append(diff.Added, dependency)
+		case wasPresent && !isKept:
+			reason := "dropped by the patches limit"
+			if !matched[key] {
+				reason = "matches no dependency-constraint"
+			}
+			diff.Dropped = append(diff.Dropped, DroppedDependency{ConfigMetadataDependency: existing[key], Reason: reason})
+		}
+	}
+
+	for id, constraint := range config.Metadata.DefaultVersions {
+		defaultVersion, err := resolveVersion(config.Metadata.Dependencies, id, constraint)
+		if err != nil {
+			return MetadataDiff{}, err
+		}
+
+		if defaultVersion == "" {
+			continue
+		}
+
+		for _, dependency := range diff.Dropped {
+			if dependency.ID == id && dependency.Version == defaultVersion {
+				diff.RemovedDefaults[id] = defaultVersion
+			}
+		}
+	}
+
+	sortDependencies(diff.Added)
+	sort.SliceStable(diff.Dropped, func(i, j int) bool {
+		return lessDependency(diff.Dropped[i].ID, diff.Dropped[i].Version, diff.Dropped[j].ID, diff.Dropped[j].Version)
+	})
+	sort.SliceStable(diff.Changed, func(i, j int) bool {
+		return lessDependency(diff.Changed[i].ID, diff.Changed[i].Version, diff.Changed[j].ID, diff.Changed[j].Version)
+	})
+
+	return diff, nil
+}
+
+// Write prints the diff in a human-readable form.
+func (d MetadataDiff) Write(w io.Writer) {
+	if d.Empty() {
+		fmt.Fprintln(w, "No changes to buildpack.toml dependencies")
+		return
+	}
+
+	for _, dependency := range d.Added {
+		fmt.Fprintf(w, "+ %s %s (%s)\n", dependency.ID, dependency.Version, dependency.Checksum)
+	}
+
+	for _, dependency := range d.Dropped {
+		fmt.Fprintf(w, "- %s %s (%s)\n", dependency.ID, dependency.Version, dependency.Reason)
+	}
+
+	for _, change := range d.Changed {
+		fmt.Fprintf(w, "~ %s %s %s\n", change.ID, change.Version, change.Field)
+		fmt.Fprintf(w, "    - %s\n", change.Old)
+		fmt.Fprintf(w, "    + %s\n", change.New)
+	}
+
+	var ids []string
+	for id := range d.RemovedDefaults {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		fmt.Fprintf(w, "! the current default version of %s (%s) would be removed\n", id, d.RemovedDefaults[id])
+	}
+}
+
+// RunDiff implements the diff subcommand and returns its exit code.
+func RunDiff(args []string, stdout io.Writer) int {
+	var buildpackTOMLPath, metadataPath string
+
+	flags := flag.NewFlagSet("diff", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	flags.StringVar(&buildpackTOMLPath, "buildpack_toml_path", "", "path to the buildpack.toml file")
+	flags.StringVar(&metadataPath, "metadata", "", "path to the metadata JSON written by the retrieval tool")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if buildpackTOMLPath == "" || metadataPath == "" {
+		fmt.Fprintln(stdout, "diff requires --buildpack_toml_path and --metadata")
+		return 2
+	}
+
+	config, err := cargo.NewBuildpackParser().Parse(buildpackTOMLPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not parse %s: %s\n", buildpackTOMLPath, err)
+		return 1
+	}
+
+	retrieved, err := readRetrievedMetadata(metadataPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not read %s: %s\n", metadataPath, err)
+		return 1
+	}
+
+	diff, err := DiffMetadata(config, retrieved)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not compute diff: %s\n", err)
+		return 1
+	}
+
+	diff.Write(stdout)
+
+	if len(diff.RemovedDefaults) > 0 {
+		return 1
+	}
+
+	return 0
+}
+
+func readRetrievedMetadata(path string) ([]cargo.ConfigMetadataDependency, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var dependencies []cargo.ConfigMetadataDependency
+	err = json.NewDecoder(file).Decode(&dependencies)
+	if err != nil {
+		return nil, err
+	}
+
+	return dependencies, nil
+}
+
+func dependencyKey(dependency cargo.ConfigMetadataDependency) string {
+	return fmt.Sprintf("%s|%s|%s|%s", dependency.ID, dependency.Version, dependency.OS, dependency.Arch)
+}
+
+func compareDependencies(old, new cargo.ConfigMetadataDependency) []DependencyChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"checksum", old.Checksum, new.Checksum},
+		{"source-checksum", old.SourceChecksum, new.SourceChecksum},
+		{"purl", old.PURL, new.PURL},
+	}
+
+	var changes []DependencyChange
+	for _, field := range fields {
+		if field.old != field.new {
+			changes = append(changes, DependencyChange{
+				ID:      new.ID,
+				Version: new.Version,
+				Field:   field.name,
+				Old:     field.old,
+				New:     field.new,
+			})
+		}
+	}
+
+	return changes
+}
+
+// applyConstraints returns the subset of dependencies that would remain in
+// buildpack.toml. For every constraint only the newest `patches` versions are
+// kept. Dependencies whose id has no constraints are always kept. It also
+// returns the keys of the dependencies that match at least one constraint.
+func applyConstraints(dependencies map[string]cargo.ConfigMetadataDependency, constraints []cargo.ConfigMetadataDependencyConstraint) (map[string]cargo.ConfigMetadataDependency, map[string]bool, error) {
+	constrained := map[string]bool{}
+	for _, constraint := range constraints {
+		constrained[constraint.ID] = true
+	}
+
+	kept := map[string]cargo.ConfigMetadataDependency{}
+	matched := map[string]bool{}
+	for key, dependency := range dependencies {
+		if !constrained[dependency.ID] {
+			kept[key] = dependency
+		}
+	}
+
+	for _, constraint := range constraints {
+		c, err := semver.NewConstraint(constraint.Constraint)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid dependency constraint %q: %w", constraint.Constraint, err)
+		}
+
+		var versions []*semver.Version
+		matches := map[string][]string{}
+		for key, dependency := range dependencies {
+			if dependency.ID != constraint.ID {
+				continue
+			}
+
+			version, err := semver.NewVersion(dependency.Version)
+			if err != nil {
+				return nil, nil, fmt.Errorf("invalid version %q for %s: %w", dependency.Version, dependency.ID, err)
+			}
+
+			if !c.Check(version) {
+				continue
+			}
+			matched[key] = true
+
+			if _, ok := matches[version.String()]; !ok {
+				versions = append(versions, version)
+			}
+			matches[version.String()] = append(matches[version.String()], key)
+		}
+
+		sort.Sort(sort.Reverse(semver.Collection(versions)))
+
+		for i, version := range versions {
+			if i >= constraint.Patches {
+				break
+			}
+
+			for _, key := range matches[version.String()] {
+				kept[key] = dependencies[key]
+			}
+		}
+	}
+
+	return kept, matched, nil
+}
+
+// resolveVersion returns the highest version of the given dependency that
+// satisfies the constraint, or an empty string when there is none.
+func resolveVersion(dependencies []cargo.ConfigMetadataDependency, id, constraint string) (string, error) {
+	c, err := semver.NewConstraint(constraint)
+	if err != nil {
+		return "", fmt.Errorf("invalid version constraint %q for %s: %w", constraint, id, err)
+	}
+
+	var highest *semver.Version
+	var resolved string
+	for _, dependency := range dependencies {
+		if dependency.ID != id {
+			continue
+		}
+
+		version, err := semver.NewVersion(dependency.Version)
+		if err != nil {
+			return "", fmt.Errorf("invalid version %q for %s: %w", dependency.Version, dependency.ID, err)
+		}
+
+		if c.Check(version) && (highest == nil || version.GreaterThan(highest)) {
+			highest = version
+			resolved = dependency.Version
+		}
+	}
+
+	return resolved, nil
+}
+
+func sortDependencies(dependencies []cargo.ConfigMetadataDependency) {
+	sort.SliceStable(dependencies, func(i, j int) bool {
+		return lessDependency(dependencies[i].ID, dependencies[i].Version, dependencies[j].ID, dependencies[j].Version)
+	})
+}
+
+// lessDependency orders dependencies by id and then by semantic version,
+// falling back to comparing the versions as strings when either is invalid.
+func lessDependency(iID, iVersion, jID, jVersion string) bool {
+	if iID != jID {
+		return iID < jID
+	}
+
+	iSemver, iErr := semver.NewVersion(iVersion)
+	jSemver, jErr := semver.NewVersion(jVersion)
+	if iErr != nil || jErr != nil {
+		return iVersion < jVersion
+	}
+
+	return iSemver.LessThan(jSemver)
+}
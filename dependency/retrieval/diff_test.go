@@ -0,0 +1,183 @@
+package main_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/paketo-buildpacks/pipenv/retrieval"
+)
+
+func testDiff(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		config cargo.Config
+	)
+
+	it.Before(func() {
+		config = cargo.Config{
+			Metadata: cargo.ConfigMetadata{
+				DefaultVersions: map[string]string{"composer": "*"},
+				Dependencies: []cargo.ConfigMetadataDependency{
+					{ID: "composer", Version: "2.10.1", Checksum: "sha256:aaa", PURL: "pkg:generic/composer@2.10.1"},
+					{ID: "composer", Version: "2.10.2", Checksum: "sha256:bbb", PURL: "pkg:generic/composer@2.10.2"},
+				},
+				DependencyConstraints: []cargo.ConfigMetadataDependencyConstraint{
+					{ID: "composer", Constraint: "2.*", Patches: 2},
+				},
+			},
+		}
+	})
+
+	context("DiffMetadata", func() {
+		it("reports added versions and versions dropped by the patches limit", func() {
+			diff, err := main.DiffMetadata(config, []cargo.ConfigMetadataDependency{
+				{ID: "composer", Version: "2.10.3", Checksum: "sha256:ccc"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(diff.Added).To(Equal([]cargo.ConfigMetadataDependency{
+				{ID: "composer", Version: "2.10.3", Checksum: "sha256:ccc"},
+			}))
+			Expect(diff.Dropped).To(Equal([]main.DroppedDependency{
+				{
+					ConfigMetadataDependency: cargo.ConfigMetadataDependency{ID: "composer", Version: "2.10.1", Checksum: "sha256:aaa", PURL: "pkg:generic/composer@2.10.1"},
+					Reason:                   "dropped by the patches limit",
+				},
+			}))
+			Expect(diff.Changed).To(BeEmpty())
+			Expect(diff.RemovedDefaults).To(BeEmpty())
+
+			buffer := bytes.NewBuffer(nil)
+			diff.Write(buffer)
+			Expect(buffer.String()).To(ContainSubstring("+ composer 2.10.3 (sha256:ccc)"))
+			Expect(buffer.String()).To(ContainSubstring("- composer 2.10.1 (dropped by the patches limit)"))
+		})
+
+		it("reports changed checksums and PURLs", func() {
+			diff, err := main.DiffMetadata(config, []cargo.ConfigMetadataDependency{
+				{ID: "composer", Version: "2.10.2", Checksum: "sha256:ddd", PURL: "pkg:generic/composer@2.10.2?checksum=ddd"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(diff.Added).To(BeEmpty())
+			Expect(diff.Dropped).To(BeEmpty())
+			Expect(diff.Changed).To(Equal([]main.DependencyChange{
+				{ID: "composer", Version: "2.10.2", Field: "checksum", Old: "sha256:bbb", New: "sha256:ddd"},
+				{ID: "composer", Version: "2.10.2", Field: "purl", Old: "pkg:generic/composer@2.10.2", New: "pkg:generic/composer@2.10.2?checksum=ddd"},
+			}))
+		})
+
+		it("reports versions that no longer match any constraint", func() {
+			config.Metadata.DependencyConstraints[0].Constraint = "2.10.2"
+
+			diff, err := main.DiffMetadata(config, nil)
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(diff.Dropped).To(HaveLen(1))
+			Expect(diff.Dropped[0].Version).To(Equal("2.10.1"))
+			Expect(diff.Dropped[0].Reason).To(Equal("matches no dependency-constraint"))
+
+			buffer := bytes.NewBuffer(nil)
+			diff.Write(buffer)
+			Expect(buffer.String()).To(ContainSubstring("- composer 2.10.1 (matches no dependency-constraint)"))
+		})
+
+		it("orders changes by semantic version", func() {
+			config.Metadata.Dependencies = append(config.Metadata.Dependencies,
+				cargo.ConfigMetadataDependency{ID: "composer", Version: "2.9.9", Checksum: "sha256:999"})
+			config.Metadata.DependencyConstraints[0].Patches = 3
+
+			diff, err := main.DiffMetadata(config, []cargo.ConfigMetadataDependency{
+				{ID: "composer", Version: "2.10.2", Checksum: "sha256:ddd", PURL: "pkg:generic/composer@2.10.2"},
+				{ID: "composer", Version: "2.9.9", Checksum: "sha256:eee"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(diff.Changed).To(Equal([]main.DependencyChange{
+				{ID: "composer", Version: "2.9.9", Field: "checksum", Old: "sha256:999", New: "sha256:eee"},
+				{ID: "composer", Version: "2.10.2", Field: "checksum", Old: "sha256:bbb", New: "sha256:ddd"},
+			}))
+		})
+
+		it("reports when the current default version would be removed", func() {
+			diff, err := main.DiffMetadata(config, []cargo.ConfigMetadataDependency{
+				{ID: "composer", Version: "2.10.3"},
+				{ID: "composer", Version: "2.10.4"},
+			})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(diff.RemovedDefaults).To(Equal(map[string]string{"composer": "2.10.2"}))
+
+			buffer := bytes.NewBuffer(nil)
+			diff.Write(buffer)
+			Expect(buffer.String()).To(ContainSubstring("! the current default version of composer (2.10.2) would be removed"))
+		})
+
+		it("reports when nothing changes", func() {
+			diff, err := main.DiffMetadata(config, nil)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(diff.Empty()).To(BeTrue())
+
+			buffer := bytes.NewBuffer(nil)
+			diff.Write(buffer)
+			Expect(buffer.String()).To(Equal("No changes to buildpack.toml dependencies\n"))
+		})
+
+		context("failure cases", func() {
+			context("when a dependency constraint is invalid", func() {
+				it.Before(func() {
+					config.Metadata.DependencyConstraints[0].Constraint = "not-a-constraint"
+				})
+
+				it("returns an error", func() {
+					_, err := main.DiffMetadata(config, nil)
+					Expect(err).To(MatchError(ContainSubstring(`invalid dependency constraint "not-a-constraint"`)))
+				})
+			})
+		})
+	})
+
+	context("RunDiff", func() {
+		var buildpackTOMLPath, metadataPath string
+
+		it.Before(func() {
+			dir := t.TempDir()
+
+			buildpackTOMLPath = filepath.Join(dir, "buildpack.toml")
+			Expect(os.WriteFile(buildpackTOMLPath, []byte(`
+[metadata]
+  [metadata.default-versions]
+    composer = "*"
+
+  [[metadata.dependencies]]
+    id = "composer"
+    version = "2.10.2"
+
+  [[metadata.dependency-constraints]]
+    constraint = "2.*"
+    id = "composer"
+    patches = 1
+`), 0600)).To(Succeed())
+
+			metadataPath = filepath.Join(dir, "metadata.json")
+			Expect(os.WriteFile(metadataPath, []byte(`[{"id": "composer", "version": "2.10.3", "target": "NONE"}]`), 0600)).To(Succeed())
+		})
+
+		it("prints the diff and exits non-zero when the default version would be removed", func() {
+			buffer := bytes.NewBuffer(nil)
+
+			code := main.RunDiff([]string{"--buildpack_toml_path", buildpackTOMLPath, "--metadata", metadataPath}, buffer)
+			Expect(code).To(Equal(1))
+			Expect(buffer.String()).To(ContainSubstring("+ composer 2.10.3"))
+			Expect(buffer.String()).To(ContainSubstring("- composer 2.10.2"))
+		})
+	})
+}
@@ -10,5 +10,10 @@ import (
 func TestUnitcomposer(t *testing.T) {
 	suite := spec.New("retrieval", spec.Report(report.Terminal{}))
 	suite("Retrieval", testRetrieval)
+	suite("Diff", testDiff)
+	suite("Mirror", testMirror)
+	suite("Provenance", testProvenance)
+	suite("Validate", testValidate)
+	suite("Channels", testChannels)
 	suite.Run(t)
 }
@@ -3,6 +3,7 @@ package main
 import (
 	"bytes"
 	"errors"
+	"flag"
 	"fmt"
 	"io"
 	"net/http"
@@ -10,6 +11,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"strings"
+	"time"
 
 	"github.com/joshuatcasey/libdependency/github"
 	"github.com/joshuatcasey/libdependency/retrieve"
@@ -53,22 +55,31 @@ func downloadToFile(url string) (filePath string, fileContents string, err error
 	return
 }
 
-func verifyASC(signature, target, pgpKey string) error {
+// provenanceDir is where GenerateMetadata writes an in-toto provenance
+// statement for every version it processes. Nothing is written when empty.
+var provenanceDir string
+
+// verifyASC checks the detached signature of target and returns the
+// fingerprint of the key that signed it.
+func verifyASC(signature, target, pgpKey string) (string, error) {
 	file, err := os.Open(target)
 	if err != nil {
-		return fmt.Errorf("could not open file: %w", err)
+		return "", fmt.Errorf("could not open file: %w", err)
 	}
 	defer file.Close()
 
 	keyring, err := openpgp.ReadArmoredKeyRing(strings.NewReader(pgpKey))
 	if err != nil {
-		return err
+		return "", err
 	}
 	signer, err := openpgp.CheckDetachedSignature(keyring, file, strings.NewReader(signature))
 	if signer == nil {
-		return fmt.Errorf("signature not accepted: %w", err)
+		return "", fmt.Errorf("signature not accepted: %w", err)
+	}
+	if err != nil {
+		return "", err
 	}
-	return err
+	return fmt.Sprintf("%X", signer.PrimaryKey.Fingerprint), nil
 }
 
 func GenerateMetadata(versionFetcher versionology.VersionFetcher) ([]versionology.Dependency, error) {
@@ -82,7 +93,7 @@ func GenerateMetadata(versionFetcher versionology.VersionFetcher) ([]versionolog
 	}
 
 	upstreamChecksumUri := fmt.Sprintf("https://getcomposer.org/download/%s/composer.phar.sha256sum", version)
-	_, upstreamChecksum, err := downloadToFile(upstreamChecksumUri)
+	upstreamChecksumPath, upstreamChecksum, err := downloadToFile(upstreamChecksumUri)
 	if err != nil {
 		return nil, fmt.Errorf("could not download %s to file", upstreamChecksumUri)
 	}
@@ -109,7 +120,7 @@ func GenerateMetadata(versionFetcher versionology.VersionFetcher) ([]versionolog
 		return nil, fmt.Errorf("could not download %s to file", ascUri)
 	}
 
-	err = verifyASC(asc, filePath, composerPublicKey)
+	signerFingerprint, err := verifyASC(asc, filePath, composerPublicKey)
 	if err != nil {
 		return nil, fmt.Errorf("could not verify signature: %w", err)
 	}
@@ -117,21 +128,50 @@ func GenerateMetadata(versionFetcher versionology.VersionFetcher) ([]versionolog
 	sha256 := strings.Split(upstreamChecksum, " ")[0]
 	checksum := fmt.Sprintf("sha256:%s", sha256)
 
+	if provenanceDir != "" {
+		upstreamChecksumFileSHA256, err := fs.NewChecksumCalculator().Sum(upstreamChecksumPath)
+		if err != nil {
+			return nil, fmt.Errorf("unable to calculate checksum of %s: %w", upstreamChecksumUri, err)
+		}
+
+		statement := NewProvenanceStatement(ProvenanceInput{
+			Version:           version,
+			SourceURI:         uri,
+			SHA256:            sha256,
+			ChecksumURI:       upstreamChecksumUri,
+			ChecksumSHA256:    upstreamChecksumFileSHA256,
+			SignatureURI:      ascUri,
+			SignerFingerprint: signerFingerprint,
+			VerifiedAt:        time.Now(),
+		})
+
+		_, err = WriteProvenance(provenanceDir, statement)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	// Download the tarball and verify the checksum
 	// Download the tarball and verify the signature
 
+	deprecationDate, err := LookupDeprecationDate(versionFetcher.Version())
+	if err != nil {
+		return nil, err
+	}
+
 	configMetadataDependency := cargo.ConfigMetadataDependency{
-		CPE:            fmt.Sprintf("cpe:2.3:a:getcomposer:composer:%s:*:*:*:*:python:*:*", version),
-		Checksum:       checksum,
-		ID:             "composer",
-		Licenses:       retrieve.LookupLicenses(uri, PharDecompress),
-		Name:           "composer",
-		PURL:           retrieve.GeneratePURL("composer", version, sha256, uri),
-		Source:         uri,
-		SourceChecksum: checksum,
-		Stacks:         []string{"*"},
-		URI:            uri,
-		Version:        version,
+		CPE:             fmt.Sprintf("cpe:2.3:a:getcomposer:composer:%s:*:*:*:*:*:*:*", version),
+		Checksum:        checksum,
+		DeprecationDate: deprecationDate,
+		ID:              "composer",
+		Licenses:        retrieve.LookupLicenses(uri, PharDecompress),
+		Name:            "composer",
+		PURL:            retrieve.GeneratePURL("composer", version, sha256, uri),
+		Source:          uri,
+		SourceChecksum:  checksum,
+		Stacks:          []string{"*"},
+		URI:             uri,
+		Version:         version,
 	}
 	return versionology.NewDependencyArray(configMetadataDependency, "NONE")
 }
@@ -178,6 +218,21 @@ func PharDecompress(artifact io.Reader, destination string) error {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "diff":
+			os.Exit(RunDiff(os.Args[2:], os.Stdout))
+		case "validate":
+			os.Exit(RunValidate(os.Args[2:], os.Stdout))
+		case "mirror":
+			os.Exit(RunMirror(os.Args[2:], os.Stdout))
+		case "channels":
+			os.Exit(RunChannels(os.Args[2:], os.Stdout))
+		}
+	}
+
+	flag.StringVar(&provenanceDir, "provenance_dir", "", "directory to write an in-toto provenance statement for each version to")
+
 	getAllVersions := github.GetAllVersions(os.Getenv("GIT_TOKEN"), "composer", "composer")
 
 	retrieve.NewMetadata("composer", getAllVersions, GenerateMetadata)
@@ -31,7 +31,7 @@ func testRetrieval(t *testing.T, context spec.G, it spec.S) {
 			Expect(metadata).To(ConsistOf(versionology.Dependency{
 				ConfigMetadataDependency: cargo.ConfigMetadataDependency{
 					Checksum:       "sha256:c252c2a2219956f88089ffc242b42c8cb9300a368fd3890d63940e4fc9652345",
-					CPE:            "cpe:2.3:a:getcomposer:composer:2.4.4:*:*:*:*:python:*:*",
+					CPE:            "cpe:2.3:a:getcomposer:composer:2.4.4:*:*:*:*:*:*:*",
 					PURL:           "pkg:generic/composer@2.4.4?checksum=c252c2a2219956f88089ffc242b42c8cb9300a368fd3890d63940e4fc9652345&download_url=https://getcomposer.org/download/2.4.4/composer.phar",
 					ID:             "composer",
 					Licenses:       []interface{}{"MIT"},
@@ -48,6 +48,21 @@ func testRetrieval(t *testing.T, context spec.G, it spec.S) {
 		})
 	})
 
+	context("LookupDeprecationDate", func() {
+		it("returns the end-of-life date of the LTS line", func() {
+			deprecationDate, err := main.LookupDeprecationDate(semver.MustParse("2.2.25"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deprecationDate).NotTo(BeNil())
+			Expect(deprecationDate.Format("2006-01-02")).To(Equal("2026-12-31"))
+		})
+
+		it("returns nil for a mainline version", func() {
+			deprecationDate, err := main.LookupDeprecationDate(semver.MustParse("2.10.2"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(deprecationDate).To(BeNil())
+		})
+	})
+
 	context("PharDecompress", func() {
 		var (
 			pharPath, destination string
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+// postal resolves file:// URIs relative to the buildpack directory rather
+// than the filesystem root. Prefixing the absolute binding path with enough
+// parent directory segments climbs back to the root from any buildpack
+// location, because excess ".." segments are dropped when the path is
+// cleaned.
+const bindingRootEscape = "../../../../../../../.."
+
+// Transport fetches a dependency given its URI.
+type Transport interface {
+	Drop(root, uri string) (io.ReadCloser, error)
+}
+
+// MirroredDependency records where a dependency was mirrored to.
+type MirroredDependency struct {
+	ID       string
+	Version  string
+	Checksum string
+	Artifact string
+	URI      string
+}
+
+// MirrorDependencies downloads every dependency listed in the given
+// buildpack.toml config into outputDir and verifies it against its checksum.
+// It then turns outputDir into a dependency-mapping binding: a "type" file and
+// one file per checksum holding the file:// URI of the local artifact, as it
+// will be seen once the binding is mounted at bindingPath.
+func MirrorDependencies(config cargo.Config, transport Transport, outputDir, bindingPath string) ([]MirroredDependency, error) {
+	err := os.MkdirAll(filepath.Join(outputDir, "artifacts"), os.ModePerm)
+	if err != nil {
+		return nil, fmt.Errorf("could not create output directory: %w", err)
+	}
+
+	err = os.WriteFile(filepath.Join(outputDir, "type"), []byte("dependency-mapping"), 0644)
+	if err != nil {
+		return nil, fmt.Errorf("could not write binding type: %w", err)
+	}
+
+	var mirrored []MirroredDependency
+	seen := map[string]bool{}
+	for _, dependency := range config.Metadata.Dependencies {
+		checksum := cargo.Checksum(dependency.Checksum)
+		if checksum.Hash() == "" {
+			return nil, fmt.Errorf("dependency %s %s has no checksum", dependency.ID, dependency.Version)
+		}
+
+		if seen[checksum.Hash()] {
+			continue
+		}
+		seen[checksum.Hash()] = true
+
+		name := path.Base(dependency.URI)
+		artifact := filepath.Join(outputDir, "artifacts", checksum.Hash(), name)
+
+		err = download(transport, dependency.URI, string(checksum), artifact)
+		if err != nil {
+			return nil, fmt.Errorf("could not mirror %s %s: %w", dependency.ID, dependency.Version, err)
+		}
+
+		uri := fmt.Sprintf("file://%s%s", bindingRootEscape, path.Join(bindingPath, "artifacts", checksum.Hash(), name))
+		err = os.WriteFile(filepath.Join(outputDir, checksum.Hash()), []byte(uri), 0644)
+		if err != nil {
+			return nil, fmt.Errorf("could not write dependency mapping: %w", err)
+		}
+
+		mirrored = append(mirrored, MirroredDependency{
+			ID:       dependency.ID,
+			Version:  dependency.Version,
+			Checksum: string(checksum),
+			Artifact: artifact,
+			URI:      uri,
+		})
+	}
+
+	return mirrored, nil
+}
+
+func download(transport Transport, uri, checksum, destination string) error {
+	bundle, err := transport.Drop("", uri)
+	if err != nil {
+		return err
+	}
+	defer bundle.Close()
+
+	err = os.MkdirAll(filepath.Dir(destination), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(destination)
+	if err != nil {
+		return err
+	}
+
+	validatedReader := cargo.NewValidatedReader(bundle, checksum)
+	_, err = io.Copy(file, validatedReader)
+	err = errors.Join(err, file.Close())
+	if err == nil {
+		var ok bool
+		ok, err = validatedReader.Valid()
+		if err == nil && !ok {
+			err = errors.New("checksum does not match")
+		}
+	}
+
+	if err != nil {
+		return errors.Join(fmt.Errorf("failed to download %s: %w", uri, err), os.RemoveAll(filepath.Dir(destination)))
+	}
+
+	return nil
+}
+
+// RunMirror implements the mirror subcommand and returns its exit code.
+func RunMirror(args []string, stdout io.Writer) int {
+	var buildpackTOMLPath, outputDir, bindingPath string
+
+	flags := flag.NewFlagSet("mirror", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	flags.StringVar(&buildpackTOMLPath, "buildpack_toml_path", "", "path to the buildpack.toml file")
+	flags.StringVar(&outputDir, "output", "", "directory to write the dependency-mapping binding to")
+	flags.StringVar(&bindingPath, "binding_path", "", "path the binding is mounted at during the build (default /platform/bindings/<output directory name>)")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if buildpackTOMLPath == "" || outputDir == "" {
+		fmt.Fprintln(stdout, "mirror requires --buildpack_toml_path and --output")
+		return 2
+	}
+
+	if bindingPath == "" {
+		absOutputDir, err := filepath.Abs(outputDir)
+		if err != nil {
+			fmt.Fprintf(stdout, "could not resolve %s: %s\n", outputDir, err)
+			return 1
+		}
+		bindingPath = path.Join("/platform/bindings", filepath.Base(absOutputDir))
+	}
+
+	if !path.IsAbs(bindingPath) {
+		fmt.Fprintf(stdout, "--binding_path must be absolute, got %q\n", bindingPath)
+		return 2
+	}
+
+	config, err := cargo.NewBuildpackParser().Parse(buildpackTOMLPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not parse %s: %s\n", buildpackTOMLPath, err)
+		return 1
+	}
+
+	mirrored, err := MirrorDependencies(config, cargo.NewTransport(), outputDir, bindingPath)
+	if err != nil {
+		fmt.Fprintln(stdout, err)
+		return 1
+	}
+
+	for _, dependency := range mirrored {
+		fmt.Fprintf(stdout, "%s %s -> %s\n", dependency.ID, dependency.Version, dependency.Artifact)
+	}
+	fmt.Fprintf(stdout, "wrote dependency-mapping binding to %s (mount it at %s)\n", outputDir, bindingPath)
+
+	return 0
+}
@@ -0,0 +1,129 @@
+package main_test
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/paketo-buildpacks/pipenv/retrieval"
+)
+
+func testMirror(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		server    *httptest.Server
+		config    cargo.Config
+		outputDir string
+		hash      string
+	)
+
+	it.Before(func() {
+		server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+			if req.URL.Path == "/download/2.10.2/composer.phar" {
+				_, _ = w.Write([]byte("some-phar-content"))
+				return
+			}
+			w.WriteHeader(http.StatusNotFound)
+		}))
+
+		sum := sha256.Sum256([]byte("some-phar-content"))
+		hash = hex.EncodeToString(sum[:])
+
+		config = cargo.Config{
+			Metadata: cargo.ConfigMetadata{
+				Dependencies: []cargo.ConfigMetadataDependency{
+					{
+						ID:       "composer",
+						Version:  "2.10.2",
+						Checksum: "sha256:" + hash,
+						URI:      server.URL + "/download/2.10.2/composer.phar",
+					},
+				},
+			},
+		}
+
+		outputDir = t.TempDir()
+	})
+
+	it.After(func() {
+		server.Close()
+	})
+
+	context("MirrorDependencies", func() {
+		it("downloads each dependency and writes a dependency-mapping binding", func() {
+			mirrored, err := main.MirrorDependencies(config, cargo.NewTransport(), outputDir, outputDir)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(mirrored).To(HaveLen(1))
+
+			content, err := os.ReadFile(filepath.Join(outputDir, "type"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("dependency-mapping"))
+
+			artifact := filepath.Join(outputDir, "artifacts", hash, "composer.phar")
+			content, err = os.ReadFile(artifact)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("some-phar-content"))
+
+			uri, err := os.ReadFile(filepath.Join(outputDir, hash))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(uri)).To(Equal(mirrored[0].URI))
+
+			// postal resolves file:// URIs relative to the buildpack directory
+			bundle, err := cargo.NewTransport().Drop("/cnb/buildpacks/paketo-buildpacks_composer/1.2.3", string(uri))
+			Expect(err).NotTo(HaveOccurred())
+			defer bundle.Close()
+
+			content, err = io.ReadAll(bundle)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(Equal("some-phar-content"))
+		})
+
+		context("failure cases", func() {
+			context("when the checksum does not match", func() {
+				it.Before(func() {
+					config.Metadata.Dependencies[0].Checksum = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+				})
+
+				it("returns an error and removes the artifact", func() {
+					_, err := main.MirrorDependencies(config, cargo.NewTransport(), outputDir, outputDir)
+					Expect(err).To(MatchError(ContainSubstring("checksum does not match")))
+
+					Expect(filepath.Join(outputDir, "artifacts", "0000000000000000000000000000000000000000000000000000000000000000")).NotTo(BeADirectory())
+				})
+			})
+
+			context("when the dependency cannot be downloaded", func() {
+				it.Before(func() {
+					config.Metadata.Dependencies[0].URI = server.URL + "/missing/composer.phar"
+				})
+
+				it("returns an error", func() {
+					_, err := main.MirrorDependencies(config, cargo.NewTransport(), outputDir, outputDir)
+					Expect(err).To(MatchError(ContainSubstring("unexpected status code 404")))
+				})
+			})
+		})
+	})
+
+	context("RunMirror", func() {
+		it("requires an absolute binding path", func() {
+			buffer := bytes.NewBuffer(nil)
+
+			code := main.RunMirror([]string{"--buildpack_toml_path", "buildpack.toml", "--output", outputDir, "--binding_path", "relative"}, buffer)
+			Expect(code).To(Equal(2))
+			Expect(buffer.String()).To(ContainSubstring(`--binding_path must be absolute, got "relative"`))
+		})
+	})
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime/debug"
+	"time"
+)
+
+const (
+	inTotoStatementType     = "https://in-toto.io/Statement/v1"
+	slsaProvenanceType      = "https://slsa.dev/provenance/v1"
+	retrievalBuilderID      = "https://github.com/paketo-buildpacks/composer/tree/main/dependency/retrieval"
+	retrievalBuildType      = "https://github.com/paketo-buildpacks/composer/dependency/retrieval@v1"
+	provenanceFileSuffix    = ".intoto.json"
+	unknownRetrievalRelease = "(devel)"
+)
+
+// retrievalRelease identifies the retrieval tool in provenance statements.
+// `go run` leaves no version or revision in the build info, so the Makefile
+// stamps the git revision with -ldflags "-X main.retrievalRelease=<sha>".
+var retrievalRelease string
+
+// ProvenanceDigest maps a digest algorithm to its hex encoded value.
+type ProvenanceDigest map[string]string
+
+// ProvenanceSubject is the artifact an in-toto statement is about.
+type ProvenanceSubject struct {
+	Name   string           `json:"name"`
+	Digest ProvenanceDigest `json:"digest"`
+}
+
+// ProvenanceResource is an input that was used to produce the subject.
+type ProvenanceResource struct {
+	URI    string           `json:"uri"`
+	Digest ProvenanceDigest `json:"digest,omitempty"`
+}
+
+// ProvenanceStatement is an in-toto v1 statement carrying a SLSA v1
+// provenance predicate for a single composer.phar.
+type ProvenanceStatement struct {
+	Type          string              `json:"_type"`
+	Subject       []ProvenanceSubject `json:"subject"`
+	PredicateType string              `json:"predicateType"`
+	Predicate     struct {
+		BuildDefinition struct {
+			BuildType            string               `json:"buildType"`
+			ExternalParameters   map[string]string    `json:"externalParameters"`
+			InternalParameters   map[string]string    `json:"internalParameters"`
+			ResolvedDependencies []ProvenanceResource `json:"resolvedDependencies"`
+		} `json:"buildDefinition"`
+		RunDetails struct {
+			Builder struct {
+				ID      string            `json:"id"`
+				Version map[string]string `json:"version"`
+			} `json:"builder"`
+			Metadata struct {
+				FinishedOn string `json:"finishedOn"`
+			} `json:"metadata"`
+		} `json:"runDetails"`
+	} `json:"predicate"`
+}
+
+// ProvenanceInput holds what the retrieval tool learned while downloading
+// and verifying a single Composer release.
+type ProvenanceInput struct {
+	Version           string
+	SourceURI         string
+	SHA256            string
+	ChecksumURI       string
+	ChecksumSHA256    string
+	SignatureURI      string
+	SignerFingerprint string
+	VerifiedAt        time.Time
+}
+
+// NewProvenanceStatement builds the in-toto statement for the given input.
+func NewProvenanceStatement(input ProvenanceInput) ProvenanceStatement {
+	var statement ProvenanceStatement
+	statement.Type = inTotoStatementType
+	statement.PredicateType = slsaProvenanceType
+	statement.Subject = []ProvenanceSubject{
+		{Name: "composer.phar", Digest: ProvenanceDigest{"sha256": input.SHA256}},
+	}
+
+	definition := &statement.Predicate.BuildDefinition
+	definition.BuildType = retrievalBuildType
+	definition.ExternalParameters = map[string]string{
+		"version": input.Version,
+		"uri":     input.SourceURI,
+	}
+	definition.InternalParameters = map[string]string{
+		"signerFingerprint": input.SignerFingerprint,
+	}
+	definition.ResolvedDependencies = []ProvenanceResource{
+		{URI: input.SourceURI, Digest: ProvenanceDigest{"sha256": input.SHA256}},
+		{URI: input.ChecksumURI, Digest: ProvenanceDigest{"sha256": input.ChecksumSHA256}},
+		{URI: input.SignatureURI},
+	}
+
+	details := &statement.Predicate.RunDetails
+	details.Builder.ID = retrievalBuilderID
+	details.Builder.Version = map[string]string{"retrieval": retrievalVersion()}
+	details.Metadata.FinishedOn = input.VerifiedAt.UTC().Format(time.RFC3339)
+
+	return statement
+}
+
+// WriteProvenance writes the statement to <dir>/<sha256>.intoto.json, the
+// location the buildpack looks for it under its provenance directory.
+func WriteProvenance(dir string, statement ProvenanceStatement) (string, error) {
+	err := os.MkdirAll(dir, os.ModePerm)
+	if err != nil {
+		return "", fmt.Errorf("could not create provenance directory: %w", err)
+	}
+
+	content, err := json.MarshalIndent(statement, "", "  ")
+	if err != nil {
+		return "", err
+	}
+
+	path := filepath.Join(dir, statement.Subject[0].Digest["sha256"]+provenanceFileSuffix)
+	err = os.WriteFile(path, append(content, '\n'), 0644)
+	if err != nil {
+		return "", fmt.Errorf("could not write provenance: %w", err)
+	}
+
+	return path, nil
+}
+
+func retrievalVersion() string {
+	if retrievalRelease != "" {
+		return retrievalRelease
+	}
+
+	info, ok := debug.ReadBuildInfo()
+	if !ok || info.Main.Version == "" {
+		return unknownRetrievalRelease
+	}
+
+	version := info.Main.Version
+	for _, setting := range info.Settings {
+		if setting.Key == "vcs.revision" {
+			version = fmt.Sprintf("%s+%s", version, setting.Value)
+		}
+	}
+
+	return version
+}
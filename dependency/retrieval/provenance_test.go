@@ -0,0 +1,92 @@
+package main_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/paketo-buildpacks/pipenv/retrieval"
+)
+
+func testProvenance(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		input main.ProvenanceInput
+	)
+
+	it.Before(func() {
+		input = main.ProvenanceInput{
+			Version:           "2.10.2",
+			SourceURI:         "https://getcomposer.org/download/2.10.2/composer.phar",
+			SHA256:            "5ee7125f8a30a34d246cefdc0bc85b8a783b28f2aec968994118512350d28027",
+			ChecksumURI:       "https://getcomposer.org/download/2.10.2/composer.phar.sha256sum",
+			ChecksumSHA256:    "some-checksum-file-sha",
+			SignatureURI:      "https://getcomposer.org/download/2.10.2/composer.phar.asc",
+			SignerFingerprint: "161DFBE342889F01DDAC4E61CBB3D576F2A0946F",
+			VerifiedAt:        time.Date(2026, 10, 19, 12, 0, 0, 0, time.UTC),
+		}
+	})
+
+	context("WriteProvenance", func() {
+		it("writes an in-toto statement named after the checksum", func() {
+			dir := t.TempDir()
+
+			path, err := main.WriteProvenance(dir, main.NewProvenanceStatement(input))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(path).To(Equal(filepath.Join(dir, "5ee7125f8a30a34d246cefdc0bc85b8a783b28f2aec968994118512350d28027.intoto.json")))
+
+			content, err := os.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(string(content)).To(MatchJSON(`{
+				"_type": "https://in-toto.io/Statement/v1",
+				"subject": [
+					{
+						"name": "composer.phar",
+						"digest": {"sha256": "5ee7125f8a30a34d246cefdc0bc85b8a783b28f2aec968994118512350d28027"}
+					}
+				],
+				"predicateType": "https://slsa.dev/provenance/v1",
+				"predicate": {
+					"buildDefinition": {
+						"buildType": "https://github.com/paketo-buildpacks/composer/dependency/retrieval@v1",
+						"externalParameters": {
+							"version": "2.10.2",
+							"uri": "https://getcomposer.org/download/2.10.2/composer.phar"
+						},
+						"internalParameters": {
+							"signerFingerprint": "161DFBE342889F01DDAC4E61CBB3D576F2A0946F"
+						},
+						"resolvedDependencies": [
+							{
+								"uri": "https://getcomposer.org/download/2.10.2/composer.phar",
+								"digest": {"sha256": "5ee7125f8a30a34d246cefdc0bc85b8a783b28f2aec968994118512350d28027"}
+							},
+							{
+								"uri": "https://getcomposer.org/download/2.10.2/composer.phar.sha256sum",
+								"digest": {"sha256": "some-checksum-file-sha"}
+							},
+							{
+								"uri": "https://getcomposer.org/download/2.10.2/composer.phar.asc"
+							}
+						]
+					},
+					"runDetails": {
+						"builder": {
+							"id": "https://github.com/paketo-buildpacks/composer/tree/main/dependency/retrieval",
+							"version": {"retrieval": "(devel)"}
+						},
+						"metadata": {
+							"finishedOn": "2026-10-19T12:00:00Z"
+						}
+					}
+				}
+			}`))
+		})
+	})
+}
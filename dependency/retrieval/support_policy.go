@@ -0,0 +1,45 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/Masterminds/semver/v3"
+)
+
+// supportPolicy maps Composer release lines to the date after which upstream
+// stops supporting them. The 2.2 line is the long-term support release for
+// PHP 5.3.2 - 7.1; mainline releases have no announced end-of-life date and
+// are therefore not listed. Add an entry when upstream announces one.
+//
+// See https://getcomposer.org/download/ for the upstream policy.
+var supportPolicy = []struct {
+	Constraint string
+	EndOfLife  string
+}{
+	{Constraint: "2.2.*", EndOfLife: "2026-12-31"},
+}
+
+// LookupDeprecationDate returns the end-of-life date of the release line the
+// given version belongs to, or nil when the line is still supported.
+func LookupDeprecationDate(version *semver.Version) (*time.Time, error) {
+	for _, policy := range supportPolicy {
+		constraint, err := semver.NewConstraint(policy.Constraint)
+		if err != nil {
+			return nil, fmt.Errorf("invalid support policy constraint %q: %w", policy.Constraint, err)
+		}
+
+		if !constraint.Check(version) {
+			continue
+		}
+
+		endOfLife, err := time.Parse("2006-01-02", policy.EndOfLife)
+		if err != nil {
+			return nil, fmt.Errorf("invalid support policy date %q: %w", policy.EndOfLife, err)
+		}
+
+		return &endOfLife, nil
+	}
+
+	return nil, nil
+}
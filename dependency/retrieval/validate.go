@@ -0,0 +1,220 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+)
+
+// allowedCPETargetSoftware lists the values accepted for the target_sw
+// component of a dependency CPE. Composer is a PHP tool, so anything else
+// (for example "python") is a copy-paste mistake.
+var allowedCPETargetSoftware = []string{"*", "php"}
+
+var sha256ChecksumPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// ValidateMetadata checks that every [[metadata.dependencies]] entry in the
+// given buildpack.toml config is internally consistent, that every
+// dependency-constraint matches at least one dependency, and that every
+// default-version resolves. It returns all problems found rather than
+// stopping at the first one.
+func ValidateMetadata(config cargo.Config) []error {
+	var problems []error
+
+	for _, dependency := range config.Metadata.Dependencies {
+		for _, problem := range validateDependency(dependency) {
+			problems = append(problems, fmt.Errorf("dependency %s %s: %s", dependency.ID, dependency.Version, problem))
+		}
+	}
+
+	for _, constraint := range config.Metadata.DependencyConstraints {
+		c, err := semver.NewConstraint(constraint.Constraint)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("dependency-constraint %s %q: invalid constraint: %w", constraint.ID, constraint.Constraint, err))
+			continue
+		}
+
+		if !anyDependencyMatches(config.Metadata.Dependencies, constraint.ID, c) {
+			problems = append(problems, fmt.Errorf("dependency-constraint %s %q: no dependency matches", constraint.ID, constraint.Constraint))
+		}
+	}
+
+	var ids []string
+	for id := range config.Metadata.DefaultVersions {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	for _, id := range ids {
+		version := config.Metadata.DefaultVersions[id]
+		c, err := semver.NewConstraint(version)
+		if err != nil {
+			problems = append(problems, fmt.Errorf("default-version %s %q: invalid constraint: %w", id, version, err))
+			continue
+		}
+
+		if !anyDependencyMatches(config.Metadata.Dependencies, id, c) {
+			problems = append(problems, fmt.Errorf("default-version %s %q: does not resolve to any dependency", id, version))
+		}
+	}
+
+	return problems
+}
+
+func validateDependency(dependency cargo.ConfigMetadataDependency) []string {
+	var problems []string
+
+	if _, err := semver.NewVersion(dependency.Version); err != nil {
+		problems = append(problems, fmt.Sprintf("version %q is not valid semver", dependency.Version))
+	}
+
+	if !sha256ChecksumPattern.MatchString(dependency.Checksum) {
+		problems = append(problems, fmt.Sprintf("checksum %q is not of the form sha256:<hex>", dependency.Checksum))
+	}
+
+	if dependency.SourceChecksum != "" && !sha256ChecksumPattern.MatchString(dependency.SourceChecksum) {
+		problems = append(problems, fmt.Sprintf("source-checksum %q is not of the form sha256:<hex>", dependency.SourceChecksum))
+	} else if dependency.SourceChecksum != "" && dependency.Source == dependency.URI && dependency.SourceChecksum != dependency.Checksum {
+		// Composer ships the upstream phar as is, so the source and the
+		// dependency are the same file
+		problems = append(problems, fmt.Sprintf("source-checksum %q does not match checksum %q although source and uri are the same file", dependency.SourceChecksum, dependency.Checksum))
+	}
+
+	if dependency.URI == "" {
+		problems = append(problems, "uri is missing")
+	} else if !strings.Contains(dependency.URI, dependency.Version) {
+		problems = append(problems, fmt.Sprintf("uri %q does not contain the version", dependency.URI))
+	}
+
+	if dependency.Source != "" && !strings.Contains(dependency.Source, dependency.Version) {
+		problems = append(problems, fmt.Sprintf("source %q does not contain the version", dependency.Source))
+	}
+
+	problems = append(problems, validatePURL(dependency)...)
+	problems = append(problems, validateCPE(dependency)...)
+
+	return problems
+}
+
+func validatePURL(dependency cargo.ConfigMetadataDependency) []string {
+	if dependency.PURL == "" {
+		return []string{"purl is missing"}
+	}
+
+	nameAndVersion, query, _ := strings.Cut(dependency.PURL, "?")
+
+	var problems []string
+	if !strings.HasSuffix(nameAndVersion, "@"+dependency.Version) {
+		problems = append(problems, fmt.Sprintf("purl %q does not end in @%s", nameAndVersion, dependency.Version))
+	}
+
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return append(problems, fmt.Sprintf("purl query %q could not be parsed: %s", query, err))
+	}
+
+	if checksum := values.Get("checksum"); checksum != cargo.Checksum(dependency.Checksum).Hash() {
+		problems = append(problems, fmt.Sprintf("purl checksum %q does not match checksum %q", checksum, dependency.Checksum))
+	}
+
+	if downloadURL := values.Get("download_url"); downloadURL != dependency.URI {
+		problems = append(problems, fmt.Sprintf("purl download_url %q does not match uri %q", downloadURL, dependency.URI))
+	}
+
+	return problems
+}
+
+func validateCPE(dependency cargo.ConfigMetadataDependency) []string {
+	if dependency.CPE == "" {
+		return []string{"cpe is missing"}
+	}
+
+	// cpe:2.3:part:vendor:product:version:update:edition:language:sw_edition:target_sw:target_hw:other
+	components := strings.Split(dependency.CPE, ":")
+	if len(components) != 13 || components[0] != "cpe" || components[1] != "2.3" {
+		return []string{fmt.Sprintf("cpe %q is not a CPE 2.3 formatted string", dependency.CPE)}
+	}
+
+	var problems []string
+	if components[5] != dependency.Version {
+		problems = append(problems, fmt.Sprintf("cpe version %q does not match version", components[5]))
+	}
+
+	targetSoftware := components[10]
+	allowed := false
+	for _, value := range allowedCPETargetSoftware {
+		if targetSoftware == value {
+			allowed = true
+		}
+	}
+
+	if !allowed {
+		problems = append(problems, fmt.Sprintf("cpe target_sw %q should be one of [%s]", targetSoftware, strings.Join(allowedCPETargetSoftware, ", ")))
+	}
+
+	return problems
+}
+
+func anyDependencyMatches(dependencies []cargo.ConfigMetadataDependency, id string, constraint *semver.Constraints) bool {
+	for _, dependency := range dependencies {
+		if dependency.ID != id {
+			continue
+		}
+
+		version, err := semver.NewVersion(dependency.Version)
+		if err != nil {
+			continue
+		}
+
+		if constraint.Check(version) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// RunValidate implements the validate subcommand and returns its exit code.
+func RunValidate(args []string, stdout io.Writer) int {
+	var buildpackTOMLPath string
+
+	flags := flag.NewFlagSet("validate", flag.ContinueOnError)
+	flags.SetOutput(stdout)
+	flags.StringVar(&buildpackTOMLPath, "buildpack_toml_path", "", "path to the buildpack.toml file")
+
+	err := flags.Parse(args)
+	if err != nil {
+		return 2
+	}
+
+	if buildpackTOMLPath == "" {
+		fmt.Fprintln(stdout, "validate requires --buildpack_toml_path")
+		return 2
+	}
+
+	config, err := cargo.NewBuildpackParser().Parse(buildpackTOMLPath)
+	if err != nil {
+		fmt.Fprintf(stdout, "could not parse %s: %s\n", buildpackTOMLPath, err)
+		return 1
+	}
+
+	problems := ValidateMetadata(config)
+	for _, problem := range problems {
+		fmt.Fprintln(stdout, problem)
+	}
+
+	if len(problems) > 0 {
+		fmt.Fprintf(stdout, "found %d problem(s) in %s\n", len(problems), buildpackTOMLPath)
+		return 1
+	}
+
+	fmt.Fprintf(stdout, "%s is valid\n", buildpackTOMLPath)
+	return 0
+}
@@ -0,0 +1,123 @@
+package main_test
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/sclevine/spec"
+
+	. "github.com/onsi/gomega"
+
+	"github.com/paketo-buildpacks/pipenv/retrieval"
+)
+
+func testValidate(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		config cargo.Config
+	)
+
+	it.Before(func() {
+		config = cargo.Config{
+			Metadata: cargo.ConfigMetadata{
+				DefaultVersions: map[string]string{"composer": "*"},
+				Dependencies: []cargo.ConfigMetadataDependency{
+					{
+						Checksum:       "sha256:345b9c6a98da5c30dcbd4b0d99fc8710bf0ae98a3898eea18f7b2ad9dec93f06",
+						CPE:            "cpe:2.3:a:getcomposer:composer:2.10.1:*:*:*:*:*:*:*",
+						ID:             "composer",
+						PURL:           "pkg:generic/composer@2.10.1?checksum=345b9c6a98da5c30dcbd4b0d99fc8710bf0ae98a3898eea18f7b2ad9dec93f06&download_url=https://getcomposer.org/download/2.10.1/composer.phar",
+						Source:         "https://getcomposer.org/download/2.10.1/composer.phar",
+						SourceChecksum: "sha256:345b9c6a98da5c30dcbd4b0d99fc8710bf0ae98a3898eea18f7b2ad9dec93f06",
+						URI:            "https://getcomposer.org/download/2.10.1/composer.phar",
+						Version:        "2.10.1",
+					},
+				},
+				DependencyConstraints: []cargo.ConfigMetadataDependencyConstraint{
+					{ID: "composer", Constraint: "2.*", Patches: 2},
+				},
+			},
+		}
+	})
+
+	context("ValidateMetadata", func() {
+		it("returns no problems for consistent metadata", func() {
+			Expect(main.ValidateMetadata(config)).To(BeEmpty())
+		})
+
+		it("returns no problems for the buildpack.toml in this repository", func() {
+			config, err := cargo.NewBuildpackParser().Parse(filepath.Join("..", "..", "buildpack.toml"))
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(main.ValidateMetadata(config)).To(BeEmpty())
+		})
+
+		context("when the metadata is inconsistent", func() {
+			it.Before(func() {
+				dependency := config.Metadata.Dependencies[0]
+				dependency.CPE = "cpe:2.3:a:getcomposer:composer:2.10.0:*:*:*:*:python:*:*"
+				dependency.PURL = "pkg:generic/composer@2.10.1?checksum=abc&download_url=https://example.com/composer.phar"
+				dependency.URI = "https://getcomposer.org/download/latest/composer.phar"
+				dependency.SourceChecksum = "md5:abc"
+				config.Metadata.Dependencies[0] = dependency
+
+				config.Metadata.DependencyConstraints = append(config.Metadata.DependencyConstraints,
+					cargo.ConfigMetadataDependencyConstraint{ID: "composer", Constraint: "1.*", Patches: 1})
+				config.Metadata.DefaultVersions["composer"] = "3.*"
+			})
+
+			it("reports every problem", func() {
+				problems := main.ValidateMetadata(config)
+
+				var messages []string
+				for _, problem := range problems {
+					messages = append(messages, problem.Error())
+				}
+
+				Expect(messages).To(ConsistOf(
+					`dependency composer 2.10.1: source-checksum "md5:abc" is not of the form sha256:<hex>`,
+					`dependency composer 2.10.1: uri "https://getcomposer.org/download/latest/composer.phar" does not contain the version`,
+					`dependency composer 2.10.1: purl checksum "abc" does not match checksum "sha256:345b9c6a98da5c30dcbd4b0d99fc8710bf0ae98a3898eea18f7b2ad9dec93f06"`,
+					`dependency composer 2.10.1: purl download_url "https://example.com/composer.phar" does not match uri "https://getcomposer.org/download/latest/composer.phar"`,
+					`dependency composer 2.10.1: cpe version "2.10.0" does not match version`,
+					`dependency composer 2.10.1: cpe target_sw "python" should be one of [*, php]`,
+					`dependency-constraint composer "1.*": no dependency matches`,
+					`default-version composer "3.*": does not resolve to any dependency`,
+				))
+			})
+		})
+
+		context("when the source checksum differs from the checksum of the same file", func() {
+			it.Before(func() {
+				config.Metadata.Dependencies[0].SourceChecksum = "sha256:5ee7125f8a30a34d246cefdc0bc85b8a783b28f2aec968994118512350d28027"
+			})
+
+			it("reports the mismatch", func() {
+				problems := main.ValidateMetadata(config)
+				Expect(problems).To(HaveLen(1))
+				Expect(problems[0]).To(MatchError(`dependency composer 2.10.1: source-checksum "sha256:5ee7125f8a30a34d246cefdc0bc85b8a783b28f2aec968994118512350d28027" does not match checksum "sha256:345b9c6a98da5c30dcbd4b0d99fc8710bf0ae98a3898eea18f7b2ad9dec93f06" although source and uri are the same file`))
+			})
+		})
+	})
+
+	context("RunValidate", func() {
+		it("validates the given buildpack.toml", func() {
+			buffer := bytes.NewBuffer(nil)
+
+			code := main.RunValidate([]string{"--buildpack_toml_path", filepath.Join("..", "..", "buildpack.toml")}, buffer)
+			Expect(code).To(Equal(0), buffer.String())
+			Expect(buffer.String()).To(ContainSubstring("is valid"))
+		})
+
+		it("exits non-zero when the buildpack.toml cannot be read", func() {
+			buffer := bytes.NewBuffer(nil)
+
+			code := main.RunValidate([]string{"--buildpack_toml_path", "no-such-file"}, buffer)
+			Expect(code).To(Equal(1))
+			Expect(buffer.String()).To(ContainSubstring("could not parse no-such-file"))
+		})
+	})
+}
@@ -6,8 +6,13 @@ import (
 	"github.com/paketo-buildpacks/packit/v2"
 )
 
-func Detect() packit.DetectFunc {
+func Detect(logger Logger) packit.DetectFunc {
 	return func(context packit.DetectContext) (packit.DetectResult, error) {
+		provisions := []packit.BuildPlanProvision{
+			{
+				Name: "composer",
+			},
+		}
 		var requirements []packit.BuildPlanRequirement
 
 		if version, ok := os.LookupEnv("BP_COMPOSER_VERSION"); ok {
@@ -20,13 +25,44 @@ func Detect() packit.DetectFunc {
 			})
 		}
 
+		// The scripts run through Composer, which needs PHP at launch. Checking
+		// the script names here fails detection rather than the build.
+		processes, err := launchProcesses(context.WorkingDir)
+		if err != nil {
+			return packit.DetectResult{}, err
+		}
+
+		if len(processes) > 0 {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name:     "php",
+				Metadata: PHPMetadata{Launch: true},
+			})
+		}
+
+		// Framework detection is informational, so an unreadable lock file
+		// must not keep Composer from being provided
+		frameworks, err := detectFrameworks(context.WorkingDir)
+		if err != nil {
+			logger.Warning("skipping PHP framework detection: %s", err)
+			frameworks = nil
+		}
+
+		// The buildpack requires what it provides, so that the frameworks end
+		// up in the build plan for later buildpacks to read
+		if len(frameworks) > 0 {
+			provisions = append(provisions, packit.BuildPlanProvision{Name: PHPFramework})
+		}
+
+		for _, framework := range frameworks {
+			requirements = append(requirements, packit.BuildPlanRequirement{
+				Name:     PHPFramework,
+				Metadata: framework,
+			})
+		}
+
 		return packit.DetectResult{
 			Plan: packit.BuildPlan{
-				Provides: []packit.BuildPlanProvision{
-					{
-						Name: "composer",
-					},
-				},
+				Provides: provisions,
 				Requires: requirements,
 			},
 		}, nil
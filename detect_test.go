@@ -1,12 +1,16 @@
 package composer_test
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/paketo-buildpacks/composer"
 
 	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
 	"github.com/sclevine/spec"
 
 	. "github.com/onsi/gomega"
@@ -16,11 +20,13 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 	var (
 		Expect = NewWithT(t).Expect
 
+		buffer *bytes.Buffer
 		detect packit.DetectFunc
 	)
 
 	it.Before(func() {
-		detect = composer.Detect()
+		buffer = bytes.NewBuffer(nil)
+		detect = composer.Detect(composer.NewTextLogger(scribe.NewEmitter(buffer)))
 	})
 
 	it.After(func() {
@@ -67,4 +73,155 @@ func testDetect(t *testing.T, context spec.G, it spec.S) {
 			}))
 		})
 	})
+
+	context("when BP_COMPOSER_PROCESSES is set", func() {
+		var workingDir string
+
+		it.Before(func() {
+			workingDir = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(workingDir, "composer.json"), []byte(`{"scripts": {"serve": "php artisan serve", "queue:work": "php artisan queue:work"}}`), 0600)).To(Succeed())
+
+			Expect(os.Setenv("BP_COMPOSER_PROCESSES", "serve,queue:work")).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("BP_COMPOSER_PROCESSES")).To(Succeed())
+		})
+
+		it(`requires "php" at launch`, func() {
+			detectResult, err := detect(packit.DetectContext{WorkingDir: workingDir})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(detectResult.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "composer"},
+				},
+				Requires: []packit.BuildPlanRequirement{
+					{
+						Name:     "php",
+						Metadata: composer.PHPMetadata{Launch: true},
+					},
+				},
+			}))
+		})
+
+		context("failure cases", func() {
+			context("when a script is not defined in composer.json", func() {
+				it.Before(func() {
+					Expect(os.Setenv("BP_COMPOSER_PROCESSES", "serve,missing")).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := detect(packit.DetectContext{WorkingDir: workingDir})
+					Expect(err).To(MatchError(fmt.Sprintf(`BP_COMPOSER_PROCESSES names script "missing", which is not defined in the scripts of %s`, filepath.Join(workingDir, "composer.json"))))
+				})
+			})
+
+			context("when composer.json does not exist", func() {
+				it.Before(func() {
+					Expect(os.Remove(filepath.Join(workingDir, "composer.json"))).To(Succeed())
+				})
+
+				it("returns an error", func() {
+					_, err := detect(packit.DetectContext{WorkingDir: workingDir})
+					Expect(err).To(MatchError(fmt.Sprintf("BP_COMPOSER_PROCESSES is set but %s does not exist", filepath.Join(workingDir, "composer.json"))))
+				})
+			})
+		})
+	})
+
+	context("when composer.lock contains a well-known framework", func() {
+		var workingDir string
+
+		it.Before(func() {
+			workingDir = t.TempDir()
+			Expect(os.WriteFile(filepath.Join(workingDir, "composer.lock"), []byte(`{
+  "content-hash": "some-hash",
+  "packages": [
+    {"name": "laravel/framework", "version": "v10.48.4"},
+    {"name": "monolog/monolog", "version": "3.5.0"},
+    {"name": "symfony/framework-bundle", "version": "v6.4.4"}
+  ],
+  "packages-dev": [
+    {"name": "slim/slim", "version": "4.12.0"}
+  ]
+}`), 0600)).To(Succeed())
+		})
+
+		it.After(func() {
+			Expect(os.Unsetenv("COMPOSER")).To(Succeed())
+		})
+
+		it(`provides and requires "php-framework" with the frameworks it found`, func() {
+			detectResult, err := detect(packit.DetectContext{WorkingDir: workingDir})
+			Expect(err).NotTo(HaveOccurred())
+
+			Expect(detectResult.Plan).To(Equal(packit.BuildPlan{
+				Provides: []packit.BuildPlanProvision{
+					{Name: "composer"},
+					{Name: "php-framework"},
+				},
+				Requires: []packit.BuildPlanRequirement{
+					{
+						Name: "php-framework",
+						Metadata: composer.FrameworkMetadata{
+							Name:    "laravel",
+							Package: "laravel/framework",
+							Version: "v10.48.4",
+						},
+					},
+					{
+						Name: "php-framework",
+						Metadata: composer.FrameworkMetadata{
+							Name:    "symfony",
+							Package: "symfony/framework-bundle",
+							Version: "v6.4.4",
+						},
+					},
+				},
+			}))
+		})
+
+		context("when COMPOSER renames composer.json", func() {
+			it.Before(func() {
+				Expect(os.Setenv("COMPOSER", "composer-other.json")).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(workingDir, "composer-other.lock"), []byte(`{"packages": [{"name": "drupal/core", "version": "10.2.3"}]}`), 0600)).To(Succeed())
+			})
+
+			it("reads the lock file named after it", func() {
+				detectResult, err := detect(packit.DetectContext{WorkingDir: workingDir})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(detectResult.Plan.Requires).To(Equal([]packit.BuildPlanRequirement{
+					{
+						Name: "php-framework",
+						Metadata: composer.FrameworkMetadata{
+							Name:    "drupal",
+							Package: "drupal/core",
+							Version: "10.2.3",
+						},
+					},
+				}))
+			})
+		})
+
+		context("when composer.lock is malformed", func() {
+			it.Before(func() {
+				Expect(os.WriteFile(filepath.Join(workingDir, "composer.lock"), []byte("%%%"), 0600)).To(Succeed())
+			})
+
+			it("provides composer without frameworks and logs why", func() {
+				detectResult, err := detect(packit.DetectContext{WorkingDir: workingDir})
+				Expect(err).NotTo(HaveOccurred())
+
+				Expect(detectResult.Plan).To(Equal(packit.BuildPlan{
+					Provides: []packit.BuildPlanProvision{
+						{Name: "composer"},
+					},
+				}))
+
+				Expect(buffer.String()).To(ContainSubstring(fmt.Sprintf("WARNING: skipping PHP framework detection: failed to parse %s", filepath.Join(workingDir, "composer.lock"))))
+			})
+		})
+	})
 }
@@ -0,0 +1,72 @@
+package composer
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2/postal"
+)
+
+// The values accepted by BP_COMPOSER_EOL_POLICY.
+const (
+	EOLPolicyWarn   = "warn"
+	EOLPolicyFail   = "fail"
+	EOLPolicyIgnore = "ignore"
+)
+
+// DefaultEOLWarningDays is how many days before the deprecation date of the
+// selected dependency the build starts to warn about it, unless overridden by
+// BP_COMPOSER_EOL_WARNING_DAYS.
+const DefaultEOLWarningDays = 30
+
+// checkEndOfLife logs a warning when the given dependency is past, or within
+// the warning window of, its deprecation date. When BP_COMPOSER_EOL_POLICY is
+// "fail" a dependency that is past its deprecation date is an error instead.
+func checkEndOfLife(logger Logger, dependency postal.Dependency, now time.Time) error {
+	policy := EOLPolicyWarn
+	if value, ok := os.LookupEnv("BP_COMPOSER_EOL_POLICY"); ok && value != "" {
+		policy = strings.ToLower(value)
+	}
+
+	switch policy {
+	case EOLPolicyWarn, EOLPolicyFail:
+	case EOLPolicyIgnore:
+		return nil
+	default:
+		return fmt.Errorf("invalid value for BP_COMPOSER_EOL_POLICY %q: must be one of %q, %q or %q", policy, EOLPolicyWarn, EOLPolicyFail, EOLPolicyIgnore)
+	}
+
+	warningDays := DefaultEOLWarningDays
+	if value, ok := os.LookupEnv("BP_COMPOSER_EOL_WARNING_DAYS"); ok && value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil || days < 0 {
+			return fmt.Errorf("invalid value for BP_COMPOSER_EOL_WARNING_DAYS %q: must be a non-negative integer", value)
+		}
+		warningDays = days
+	}
+
+	if dependency.DeprecationDate.IsZero() {
+		return nil
+	}
+
+	eol := dependency.DeprecationDate.Format("2006-01-02")
+
+	switch {
+	case !now.Before(dependency.DeprecationDate):
+		if policy == EOLPolicyFail {
+			return fmt.Errorf("selected Composer version %s reached end of life on %s: select a supported version or set BP_COMPOSER_EOL_POLICY=%s to build anyway", dependency.Version, eol, EOLPolicyWarn)
+		}
+
+		logger.Warning("Composer %s reached end of life on %s and no longer receives security fixes", dependency.Version, eol)
+		logger.Break()
+
+	case now.Add(time.Duration(warningDays) * 24 * time.Hour).After(dependency.DeprecationDate):
+		logger.Warning("Composer %s reaches end of life on %s", dependency.Version, eol)
+		logger.Break()
+	}
+
+	return nil
+}
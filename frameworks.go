@@ -0,0 +1,74 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// PHPFramework is the name of the build plan entry that describes the
+// frameworks an application uses.
+const PHPFramework = "php-framework"
+
+// frameworkPackages maps the Composer package that identifies a framework to
+// the name of that framework.
+var frameworkPackages = []struct {
+	Package string
+	Name    string
+}{
+	{Package: "laravel/framework", Name: "laravel"},
+	{Package: "symfony/framework-bundle", Name: "symfony"},
+	{Package: "drupal/core", Name: "drupal"},
+	{Package: "slim/slim", Name: "slim"},
+	{Package: "laminas/laminas-mvc", Name: "laminas"},
+}
+
+// composerLockPath returns the path of the lock file that belongs to the
+// composer.json of the application, which Composer names after it.
+func composerLockPath(workingDir string) string {
+	return strings.TrimSuffix(composerJSONPath(workingDir), ".json") + ".lock"
+}
+
+// detectFrameworks returns the well-known frameworks among the packages in
+// the composer.lock of the application, in the order of frameworkPackages.
+func detectFrameworks(workingDir string) ([]FrameworkMetadata, error) {
+	path := composerLockPath(workingDir)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var lock struct {
+		Packages []struct {
+			Name    string `json:"name"`
+			Version string `json:"version"`
+		} `json:"packages"`
+	}
+	err = json.Unmarshal(content, &lock)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	versions := map[string]string{}
+	for _, p := range lock.Packages {
+		versions[p.Name] = p.Version
+	}
+
+	var frameworks []FrameworkMetadata
+	for _, framework := range frameworkPackages {
+		if version, ok := versions[framework.Package]; ok {
+			frameworks = append(frameworks, FrameworkMetadata{
+				Name:    framework.Name,
+				Package: framework.Package,
+				Version: version,
+			})
+		}
+	}
+
+	return frameworks, nil
+}
@@ -11,5 +11,8 @@ func TestUnitcomposer(t *testing.T) {
 	suite := spec.New("composer", spec.Report(report.Terminal{}))
 	suite("Detect", testDetect, spec.Sequential())
 	suite("Build", testBuild)
+	suite("BundledPackages", testBundledPackages)
+	suite("Logger", testLogger)
+	suite("SBOMGenerator", testSBOMGenerator)
 	suite.Run(t)
 }
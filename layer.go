@@ -0,0 +1,233 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/chronos"
+	"github.com/paketo-buildpacks/packit/v2/fs"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+)
+
+// layerLayoutVersion is recorded in the metadata of every Composer layer and
+// changes whenever the files in the layer move. Layers from before the phar
+// moved to libexec behind a wrapper script have no layout version.
+const layerLayoutVersion = "2"
+
+// layerContributor installs a Composer dependency into a layer, or reuses the
+// layer when it already holds an intact copy of the same dependency.
+type layerContributor struct {
+	logger            Logger
+	dependencyManager DependencyManager
+	sbomGenerator     SBOMGenerator
+	env               Executable
+	clock             chronos.Clock
+	context           packit.BuildContext
+	wrapperSettings   wrapperSettings
+	launch, build     bool
+
+	// custom is set when the dependency is a custom composer.phar, which may
+	// not bundle its libraries the way a Composer release does.
+	custom bool
+}
+
+// contribute installs the phar of the dependency into the layer's libexec
+// directory and writes a wrapper script that runs it as bin/<command>. It
+// also returns what it did for the build report.
+func (c layerContributor) contribute(layer packit.Layer, dependency postal.Dependency, command string) (packit.Layer, ReportLayer, error) {
+	logger := c.logger
+
+	report := ReportLayer{
+		Name:     layer.Name,
+		Command:  command,
+		Version:  dependency.Version,
+		Checksum: dependency.Checksum,
+		Decision: "installed",
+	}
+
+	libexecPath := filepath.Join(layer.Path, "libexec")
+	pharPath := filepath.Join(libexecPath, PharFilename)
+	wrapperPath := filepath.Join(layer.Path, "bin", command)
+
+	generateSBOM := func(formats []string) error {
+		logger.GeneratingSBOM(layer.Path)
+		var sbomContent sbom.SBOM
+		duration, err := c.clock.Measure(func() (err error) {
+			sbomContent, err = c.sbomGenerator.GenerateFromDependency(dependency, layer.Path)
+			if c.custom && errors.Is(err, ErrBundledPackages) {
+				logger.Warning("%s: the SBOM only lists the custom Composer artifact itself", err)
+				sbomContent, err = sbom.GenerateFromDependency(dependency, layer.Path)
+			}
+			return err
+		})
+		if err != nil {
+			return err
+		}
+
+		report.SBOMDurationMS = duration.Milliseconds()
+
+		logger.Completed(duration)
+		logger.Break()
+
+		logger.FormattingSBOM(formats...)
+		layer.SBOM, err = sbomContent.InFormats(formats...)
+		return err
+	}
+
+	// copyProvenance copies the provenance statement the buildpack ships for
+	// the dependency, if any, into the layer unless it is already there.
+	copyProvenance := func() error {
+		provenancePath := filepath.Join(c.context.CNBPath, "provenance", fmt.Sprintf("%s.intoto.json", cargo.Checksum(dependency.Checksum).Hash()))
+		hasProvenance, err := fs.Exists(provenancePath)
+		if err != nil || !hasProvenance {
+			return err
+		}
+
+		layerProvenancePath := filepath.Join(layer.Path, "composer.intoto.json")
+		hasLayerProvenance, err := fs.Exists(layerProvenancePath)
+		if err != nil || hasLayerProvenance {
+			return err
+		}
+
+		logger.Process("Copying provenance statement for Composer %s", dependency.Version)
+		logger.Break()
+
+		return fs.Copy(provenancePath, layerProvenancePath)
+	}
+
+	cachedChecksum, hasCachedChecksum := layer.Metadata["dependency-checksum"].(string)
+	isCached := hasCachedChecksum && cachedChecksum == dependency.Checksum
+
+	if cachedLayout, _ := layer.Metadata["layout-version"].(string); isCached && cachedLayout != layerLayoutVersion {
+		report.Decision = "reinstalled"
+
+		logger.Process("Cached layer %s was built with an older layout", layer.Path)
+		logger.Subprocess("Reinstalling Composer")
+		logger.Break()
+	} else if isCached {
+		problem, err := verifyCachedComposer(pharPath, dependency.Checksum)
+		if err != nil {
+			return packit.Layer{}, ReportLayer{}, err
+		}
+
+		if problem == "" {
+			logger.Process("Reusing cached layer %s", layer.Path)
+			logger.Break()
+
+			layer.Launch, layer.Build, layer.Cache = c.launch, c.build, c.build
+
+			err = writeWrapper(wrapperPath, c.wrapperSettings)
+			if err != nil {
+				return packit.Layer{}, ReportLayer{}, err
+			}
+
+			missingFormats, err := missingSBOMFormats(c.context.Layers.Path, layer.Name, c.context.BuildpackInfo.SBOMFormats)
+			if err != nil {
+				return packit.Layer{}, ReportLayer{}, err
+			}
+
+			if len(missingFormats) > 0 {
+				logger.Process("Regenerating SBOM missing from cached layer")
+				logger.DebugSubprocess("Missing SBOM formats: %s", strings.Join(missingFormats, ", "))
+				err = generateSBOM(c.context.BuildpackInfo.SBOMFormats)
+				if err != nil {
+					return packit.Layer{}, ReportLayer{}, err
+				}
+			}
+
+			// Layers cached before the buildpack shipped the statement lack it
+			err = copyProvenance()
+			if err != nil {
+				return packit.Layer{}, ReportLayer{}, err
+			}
+
+			report.Decision = "reused"
+
+			return layer, report, nil
+		}
+
+		report.Decision = "reinstalled"
+
+		logger.Warning("cached layer %s is corrupted: %s", layer.Path, problem)
+		logger.Subprocess("Reinstalling Composer")
+		logger.Break()
+	}
+
+	logger.Process("Executing build process")
+	logger.Subprocess("Installing Composer %s", dependency.Version)
+
+	layer, err := layer.Reset()
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+
+	layer.Launch, layer.Build, layer.Cache = c.launch, c.build, c.build
+
+	err = os.MkdirAll(libexecPath, os.ModePerm)
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+
+	duration, err := c.clock.Measure(func() error {
+		return c.dependencyManager.Deliver(dependency, c.context.CNBPath, libexecPath, c.context.Platform.Path)
+	})
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+	report.InstallDurationMS = duration.Milliseconds()
+
+	logger.Completed(duration)
+	logger.Break()
+
+	err = os.Rename(filepath.Join(libexecPath, filepath.Base(dependency.Name)), pharPath)
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+
+	logger.DebugSubprocess("Composer installed at %s", pharPath)
+
+	err = os.Chmod(pharPath, 0755)
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+
+	err = writeWrapper(wrapperPath, c.wrapperSettings)
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+
+	logger.DebugSubprocess("Composer wrapper written to %s", wrapperPath)
+
+	duration, err = c.clock.Measure(func() error {
+		return smokeTest(logger, c.env, filepath.Dir(wrapperPath), command, dependency)
+	})
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+	report.SmokeTestDurationMS = duration.Milliseconds()
+
+	err = generateSBOM(c.context.BuildpackInfo.SBOMFormats)
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+
+	err = copyProvenance()
+	if err != nil {
+		return packit.Layer{}, ReportLayer{}, err
+	}
+
+	layer.Metadata = map[string]interface{}{
+		"dependency-checksum": dependency.Checksum,
+		"layout-version":      layerLayoutVersion,
+	}
+
+	logger.DebugSubprocess("Composer layer Checksum is %s", dependency.Checksum)
+
+	return layer, report, nil
+}
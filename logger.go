@@ -0,0 +1,225 @@
+package composer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/scribe"
+)
+
+const (
+	LogFormatText = "text"
+	LogFormatJSON = "json"
+)
+
+// Logger is what the buildpack logs through, so that Build behaves the same
+// whatever the output format.
+type Logger interface {
+	Title(format string, v ...interface{})
+	Process(format string, v ...interface{})
+	Subprocess(format string, v ...interface{})
+	Action(format string, v ...interface{})
+	Break()
+
+	// Warning reports something the user should act on, without failing the
+	// build.
+	Warning(format string, v ...interface{})
+
+	// Completed reports how long the step that was just logged took.
+	Completed(duration time.Duration)
+
+	Candidates(entries []packit.BuildpackPlanEntry)
+	SelectedDependency(entry packit.BuildpackPlanEntry, dependency postal.Dependency, now time.Time)
+	GeneratingSBOM(path string)
+	FormattingSBOM(formats ...string)
+
+	DebugSubprocess(format string, v ...interface{})
+	DebugAction(format string, v ...interface{})
+	DebugBreak()
+}
+
+// NewLogger returns the logger for the given BP_LOG_FORMAT and BP_LOG_LEVEL
+// values. An empty format selects the text logger.
+func NewLogger(output io.Writer, format, level string) (Logger, error) {
+	switch strings.ToLower(format) {
+	case "", LogFormatText:
+		return NewTextLogger(scribe.NewEmitter(output).WithLevel(level)), nil
+	case LogFormatJSON:
+		return NewJSONLogger(output).WithLevel(level), nil
+	default:
+		return nil, fmt.Errorf("invalid value for BP_LOG_FORMAT %q: must be %q or %q", format, LogFormatText, LogFormatJSON)
+	}
+}
+
+// TextLogger writes the human readable output of a scribe.Emitter.
+type TextLogger struct {
+	scribe.Emitter
+}
+
+// NewTextLogger returns a Logger that writes through the given emitter.
+func NewTextLogger(emitter scribe.Emitter) TextLogger {
+	return TextLogger{Emitter: emitter}
+}
+
+// SelectedDependency logs the selected dependency without the deprecation
+// notice of scribe, which has a fixed window and ignores
+// BP_COMPOSER_EOL_POLICY. checkEndOfLife reports the end of life instead.
+func (l TextLogger) SelectedDependency(entry packit.BuildpackPlanEntry, dependency postal.Dependency, now time.Time) {
+	dependency.DeprecationDate = time.Time{}
+	l.Emitter.SelectedDependency(entry, dependency, now)
+}
+
+func (l TextLogger) Warning(format string, v ...interface{}) {
+	l.Subprocess("%s", scribe.YellowColor(fmt.Sprintf("WARNING: "+format, v...)))
+}
+
+func (l TextLogger) Completed(duration time.Duration) {
+	l.Action("Completed in %s", duration.Round(time.Millisecond))
+}
+
+func (l TextLogger) DebugSubprocess(format string, v ...interface{}) {
+	l.Debug.Subprocess(format, v...)
+}
+
+func (l TextLogger) DebugAction(format string, v ...interface{}) {
+	l.Debug.Action(format, v...)
+}
+
+func (l TextLogger) DebugBreak() {
+	l.Debug.Break()
+}
+
+// LogEvent is a single line of output of the JSON logger. Fields that do not
+// apply to an event are omitted.
+type LogEvent struct {
+	Level   string `json:"level"`
+	Event   string `json:"event"`
+	Message string `json:"message,omitempty"`
+
+	Candidates []ReportCandidate `json:"candidates,omitempty"`
+	Dependency *LogDependency    `json:"dependency,omitempty"`
+	DurationMS *int64            `json:"duration_ms,omitempty"`
+	Path       string            `json:"path,omitempty"`
+	Formats    []string          `json:"formats,omitempty"`
+}
+
+// LogDependency describes the dependency of a selected_dependency event.
+type LogDependency struct {
+	Name            string `json:"name"`
+	Version         string `json:"version"`
+	VersionSource   string `json:"version_source"`
+	Checksum        string `json:"checksum"`
+	DeprecationDate string `json:"deprecation_date,omitempty"`
+}
+
+// JSONLogger writes one JSON object per event, for BP_LOG_FORMAT=json.
+type JSONLogger struct {
+	output io.Writer
+	debug  bool
+}
+
+// NewJSONLogger returns a JSONLogger that writes to the given output and
+// drops debug events.
+func NewJSONLogger(output io.Writer) JSONLogger {
+	return JSONLogger{output: output}
+}
+
+// WithLevel returns a copy of the logger that also writes debug events when
+// the level is DEBUG.
+func (l JSONLogger) WithLevel(level string) JSONLogger {
+	l.debug = strings.ToLower(level) == "debug"
+	return l
+}
+
+func (l JSONLogger) emit(event LogEvent) {
+	if event.Level == "" {
+		event.Level = "info"
+	}
+
+	if event.Level == "debug" && !l.debug {
+		return
+	}
+
+	content, err := json.Marshal(event)
+	if err != nil {
+		// Every field of LogEvent can be marshalled
+		panic(err)
+	}
+
+	_, _ = l.output.Write(append(content, '\n'))
+}
+
+func (l JSONLogger) Title(format string, v ...interface{}) {
+	l.emit(LogEvent{Event: "title", Message: fmt.Sprintf(format, v...)})
+}
+
+func (l JSONLogger) Process(format string, v ...interface{}) {
+	l.emit(LogEvent{Event: "process", Message: fmt.Sprintf(format, v...)})
+}
+
+func (l JSONLogger) Subprocess(format string, v ...interface{}) {
+	l.emit(LogEvent{Event: "subprocess", Message: fmt.Sprintf(format, v...)})
+}
+
+func (l JSONLogger) Action(format string, v ...interface{}) {
+	l.emit(LogEvent{Event: "action", Message: fmt.Sprintf(format, v...)})
+}
+
+// Break does nothing, as every event is already on a line of its own.
+func (l JSONLogger) Break() {}
+
+func (l JSONLogger) Warning(format string, v ...interface{}) {
+	l.emit(LogEvent{Level: "warning", Event: "warning", Message: fmt.Sprintf(format, v...)})
+}
+
+func (l JSONLogger) Completed(duration time.Duration) {
+	ms := duration.Milliseconds()
+	l.emit(LogEvent{Event: "completed", DurationMS: &ms})
+}
+
+func (l JSONLogger) Candidates(entries []packit.BuildpackPlanEntry) {
+	l.emit(LogEvent{Event: "candidates", Candidates: reportCandidates(entries)})
+}
+
+func (l JSONLogger) SelectedDependency(entry packit.BuildpackPlanEntry, dependency postal.Dependency, now time.Time) {
+	source, ok := entry.Metadata["version-source"].(string)
+	if !ok {
+		source = "<unknown>"
+	}
+
+	logDependency := LogDependency{
+		Name:          dependency.Name,
+		Version:       dependency.Version,
+		VersionSource: source,
+		Checksum:      dependency.Checksum,
+	}
+
+	if !dependency.DeprecationDate.IsZero() {
+		logDependency.DeprecationDate = dependency.DeprecationDate.Format("2006-01-02")
+	}
+
+	l.emit(LogEvent{Event: "selected_dependency", Dependency: &logDependency})
+}
+
+func (l JSONLogger) GeneratingSBOM(path string) {
+	l.emit(LogEvent{Event: "generating_sbom", Path: path})
+}
+
+func (l JSONLogger) FormattingSBOM(formats ...string) {
+	l.emit(LogEvent{Event: "formatting_sbom", Formats: formats})
+}
+
+func (l JSONLogger) DebugSubprocess(format string, v ...interface{}) {
+	l.emit(LogEvent{Level: "debug", Event: "subprocess", Message: fmt.Sprintf(format, v...)})
+}
+
+func (l JSONLogger) DebugAction(format string, v ...interface{}) {
+	l.emit(LogEvent{Level: "debug", Event: "action", Message: fmt.Sprintf(format, v...)})
+}
+
+func (l JSONLogger) DebugBreak() {}
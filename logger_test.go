@@ -0,0 +1,169 @@
+package composer_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/composer"
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/sclevine/spec"
+)
+
+func testLogger(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		buffer *bytes.Buffer
+	)
+
+	it.Before(func() {
+		buffer = bytes.NewBuffer(nil)
+	})
+
+	events := func() []composer.LogEvent {
+		var events []composer.LogEvent
+		for _, line := range strings.Split(strings.TrimSpace(buffer.String()), "\n") {
+			if line == "" {
+				continue
+			}
+
+			var event composer.LogEvent
+			Expect(json.Unmarshal([]byte(line), &event)).To(Succeed(), line)
+			events = append(events, event)
+		}
+		return events
+	}
+
+	context("NewLogger", func() {
+		it("returns the text logger by default", func() {
+			logger, err := composer.NewLogger(buffer, "", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			logger.Process("Resolving Composer version")
+			Expect(buffer.String()).To(Equal("  Resolving Composer version\n"))
+		})
+
+		it("returns the JSON logger for json", func() {
+			logger, err := composer.NewLogger(buffer, "JSON", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			logger.Process("Resolving Composer version")
+			Expect(buffer.String()).To(MatchJSON(`{"level": "info", "event": "process", "message": "Resolving Composer version"}`))
+		})
+
+		context("failure cases", func() {
+			context("when the format is unknown", func() {
+				it("returns an error", func() {
+					_, err := composer.NewLogger(buffer, "xml", "")
+					Expect(err).To(MatchError(`invalid value for BP_LOG_FORMAT "xml": must be "text" or "json"`))
+				})
+			})
+		})
+	})
+
+	context("TextLogger", func() {
+		it("writes warnings and durations as text", func() {
+			logger, err := composer.NewLogger(buffer, "text", "")
+			Expect(err).NotTo(HaveOccurred())
+
+			logger.Warning("Composer %s reaches end of life on %s", "2.2.24", "2026-12-31")
+			logger.Completed(1234567 * time.Microsecond)
+
+			Expect(buffer.String()).To(ContainSubstring("WARNING: Composer 2.2.24 reaches end of life on 2026-12-31"))
+			Expect(buffer.String()).To(ContainSubstring("      Completed in 1.235s\n"))
+		})
+	})
+
+	context("JSONLogger", func() {
+		var logger composer.JSONLogger
+
+		it.Before(func() {
+			logger = composer.NewJSONLogger(buffer)
+		})
+
+		it("writes one JSON object per event", func() {
+			logger.Title("%s %s", "Composer Buildpack", "1.2.3")
+			logger.Subprocess("Installing Composer %s", "2.8.4")
+			logger.Action("Version: %s", "custom")
+			logger.Break()
+			logger.Warning("cached layer %s is corrupted", "/layers/composer")
+			logger.Completed(1500 * time.Millisecond)
+			logger.GeneratingSBOM("/layers/composer")
+			logger.FormattingSBOM("application/vnd.cyclonedx+json")
+
+			duration := int64(1500)
+			Expect(events()).To(Equal([]composer.LogEvent{
+				{Level: "info", Event: "title", Message: "Composer Buildpack 1.2.3"},
+				{Level: "info", Event: "subprocess", Message: "Installing Composer 2.8.4"},
+				{Level: "info", Event: "action", Message: "Version: custom"},
+				{Level: "warning", Event: "warning", Message: "cached layer /layers/composer is corrupted"},
+				{Level: "info", Event: "completed", DurationMS: &duration},
+				{Level: "info", Event: "generating_sbom", Path: "/layers/composer"},
+				{Level: "info", Event: "formatting_sbom", Formats: []string{"application/vnd.cyclonedx+json"}},
+			}))
+		})
+
+		it("writes candidates and the selected dependency as structured fields", func() {
+			entries := []packit.BuildpackPlanEntry{
+				{
+					Name:     "composer",
+					Metadata: map[string]interface{}{"version": "2.*", "version-source": "BP_COMPOSER_VERSION"},
+				},
+				{
+					Name: "composer",
+				},
+			}
+
+			logger.Candidates(entries)
+			logger.SelectedDependency(entries[0], postal.Dependency{
+				Name:            "composer",
+				Version:         "2.2.24",
+				Checksum:        "sha256:some-sha",
+				DeprecationDate: time.Date(2026, 12, 31, 0, 0, 0, 0, time.UTC),
+			}, time.Now())
+
+			Expect(events()).To(Equal([]composer.LogEvent{
+				{
+					Level: "info",
+					Event: "candidates",
+					Candidates: []composer.ReportCandidate{
+						{Source: "BP_COMPOSER_VERSION", Constraint: "2.*"},
+						{Source: "<unknown>", Constraint: ""},
+					},
+				},
+				{
+					Level: "info",
+					Event: "selected_dependency",
+					Dependency: &composer.LogDependency{
+						Name:            "composer",
+						Version:         "2.2.24",
+						VersionSource:   "BP_COMPOSER_VERSION",
+						Checksum:        "sha256:some-sha",
+						DeprecationDate: "2026-12-31",
+					},
+				},
+			}))
+		})
+
+		it("drops debug events unless the level is DEBUG", func() {
+			logger.DebugSubprocess("Composer installed at %s", "/layers/composer/libexec/composer.phar")
+			logger.DebugAction("PHP version 8.3.14")
+			logger.DebugBreak()
+			Expect(buffer.String()).To(BeEmpty())
+
+			logger = logger.WithLevel("DEBUG")
+			logger.DebugSubprocess("Composer installed at %s", "/layers/composer/libexec/composer.phar")
+			logger.DebugAction("PHP version 8.3.14")
+
+			Expect(events()).To(Equal([]composer.LogEvent{
+				{Level: "debug", Event: "subprocess", Message: "Composer installed at /layers/composer/libexec/composer.phar"},
+				{Level: "debug", Event: "action", Message: "PHP version 8.3.14"},
+			}))
+		})
+	})
+}
@@ -0,0 +1,158 @@
+package composer
+
+import (
+	"bufio"
+	"bytes"
+	"compress/bzip2"
+	"compress/flate"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+const (
+	pharHaltCompiler = "__HALT_COMPILER();"
+
+	pharEntryCompressionMask = 0x0000F000
+	pharEntryCompressedGZ    = 0x00001000
+	pharEntryCompressedBZ2   = 0x00002000
+)
+
+// ErrPharEntryNotFound is returned by ReadPharEntry when the archive does not
+// contain the requested file.
+var ErrPharEntryNotFound = errors.New("phar entry not found")
+
+type pharEntry struct {
+	name           string
+	compressedSize uint32
+	flags          uint32
+}
+
+// ReadPharEntry returns the uncompressed contents of the file with the given
+// name inside the phar archive at path. Only the phar file format is
+// supported; tar and zip based phars are not.
+//
+// See https://www.php.net/manual/en/phar.fileformat.phar.php for the format.
+func ReadPharEntry(path, name string) ([]byte, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	start := bytes.Index(content, []byte(pharHaltCompiler))
+	if start < 0 {
+		return nil, fmt.Errorf("failed to read phar %s: missing %s", path, pharHaltCompiler)
+	}
+
+	offset := start + len(pharHaltCompiler)
+	for _, suffix := range []string{" ?>\r\n", " ?>\n", " ?>", "?>\r\n", "?>\n", "?>"} {
+		if bytes.HasPrefix(content[offset:], []byte(suffix)) {
+			offset += len(suffix)
+			break
+		}
+	}
+
+	reader := bytes.NewReader(content[offset:])
+
+	var header struct {
+		ManifestLength uint32
+		FileCount      uint32
+		APIVersion     uint16
+		Flags          uint32
+		AliasLength    uint32
+	}
+	err = binary.Read(reader, binary.LittleEndian, &header)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read phar manifest %s: %w", path, err)
+	}
+
+	_, err = reader.Seek(int64(header.AliasLength), io.SeekCurrent)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read phar manifest %s: %w", path, err)
+	}
+
+	err = skipPharMetadata(reader)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read phar manifest %s: %w", path, err)
+	}
+
+	var entries []pharEntry
+	for i := uint32(0); i < header.FileCount; i++ {
+		var nameLength uint32
+		err = binary.Read(reader, binary.LittleEndian, &nameLength)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read phar manifest %s: %w", path, err)
+		}
+
+		entryName := make([]byte, nameLength)
+		_, err = io.ReadFull(reader, entryName)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read phar manifest %s: %w", path, err)
+		}
+
+		var entry struct {
+			UncompressedSize uint32
+			Timestamp        uint32
+			CompressedSize   uint32
+			CRC32            uint32
+			Flags            uint32
+		}
+		err = binary.Read(reader, binary.LittleEndian, &entry)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read phar manifest %s: %w", path, err)
+		}
+
+		err = skipPharMetadata(reader)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read phar manifest %s: %w", path, err)
+		}
+
+		entries = append(entries, pharEntry{
+			name:           string(entryName),
+			compressedSize: entry.CompressedSize,
+			flags:          entry.Flags,
+		})
+	}
+
+	// File contents start right after the manifest, whose length does not
+	// include the 4 bytes of the length field itself.
+	dataOffset := int64(offset) + 4 + int64(header.ManifestLength)
+	for _, entry := range entries {
+		if entry.name != name {
+			dataOffset += int64(entry.compressedSize)
+			continue
+		}
+
+		end := dataOffset + int64(entry.compressedSize)
+		if end > int64(len(content)) {
+			return nil, fmt.Errorf("failed to read %s from phar %s: entry extends past the end of the archive", name, path)
+		}
+
+		data := content[dataOffset:end]
+		switch entry.flags & pharEntryCompressionMask {
+		case 0:
+			return data, nil
+		case pharEntryCompressedGZ:
+			return io.ReadAll(flate.NewReader(bytes.NewReader(data)))
+		case pharEntryCompressedBZ2:
+			return io.ReadAll(bzip2.NewReader(bufio.NewReader(bytes.NewReader(data))))
+		default:
+			return nil, fmt.Errorf("failed to read %s from phar %s: unsupported compression flags %#x", name, path, entry.flags)
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s in %s", ErrPharEntryNotFound, name, path)
+}
+
+func skipPharMetadata(reader *bytes.Reader) error {
+	var length uint32
+	err := binary.Read(reader, binary.LittleEndian, &length)
+	if err != nil {
+		return err
+	}
+
+	_, err = reader.Seek(int64(length), io.SeekCurrent)
+	return err
+}
@@ -0,0 +1,84 @@
+package composer
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+var invalidProcessTypeCharacters = regexp.MustCompile(`[^A-Za-z0-9_.-]+`)
+
+// composerJSONPath returns the path of the composer.json of the application,
+// which the COMPOSER environment variable may rename.
+func composerJSONPath(workingDir string) string {
+	if path, ok := os.LookupEnv("COMPOSER"); ok && path != "" {
+		return filepath.Join(workingDir, path)
+	}
+
+	return filepath.Join(workingDir, "composer.json")
+}
+
+// launchProcesses returns a launch process that runs each composer.json
+// script named in BP_COMPOSER_PROCESSES. The first script named is the
+// default process.
+func launchProcesses(workingDir string) ([]packit.Process, error) {
+	names := strings.FieldsFunc(os.Getenv("BP_COMPOSER_PROCESSES"), func(r rune) bool {
+		return r == ',' || r == ' ' || r == '\t' || r == '\n'
+	})
+	if len(names) == 0 {
+		return nil, nil
+	}
+
+	path := composerJSONPath(workingDir)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, fmt.Errorf("BP_COMPOSER_PROCESSES is set but %s does not exist", path)
+		}
+		return nil, err
+	}
+
+	var composerJSON struct {
+		Scripts map[string]json.RawMessage `json:"scripts"`
+	}
+	err = json.Unmarshal(content, &composerJSON)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", path, err)
+	}
+
+	var processes []packit.Process
+	types := map[string]string{}
+	for i, name := range names {
+		if _, ok := composerJSON.Scripts[name]; !ok {
+			return nil, fmt.Errorf("BP_COMPOSER_PROCESSES names script %q, which is not defined in the scripts of %s", name, path)
+		}
+
+		// Process types may not contain characters such as the colon in
+		// "queue:work"
+		processType := strings.Trim(invalidProcessTypeCharacters.ReplaceAllString(name, "-"), "-")
+		if processType == "" {
+			return nil, fmt.Errorf("BP_COMPOSER_PROCESSES names script %q, which has no characters allowed in a process type", name)
+		}
+
+		if other, ok := types[processType]; ok {
+			return nil, fmt.Errorf("BP_COMPOSER_PROCESSES names scripts %q and %q, which both become process type %q", other, name, processType)
+		}
+		types[processType] = name
+
+		processes = append(processes, packit.Process{
+			Type:    processType,
+			Command: "composer",
+			// Composer stops scripts after 300 seconds unless told otherwise
+			Args:    []string{"run-script", "--timeout=0", name},
+			Default: i == 0,
+		})
+	}
+
+	return processes, nil
+}
@@ -0,0 +1,123 @@
+package composer
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+
+	"github.com/paketo-buildpacks/packit/v2"
+)
+
+// ReportFilename is the name of the build report written into the Composer
+// layer.
+const ReportFilename = "report.json"
+
+// BuildReport is a machine-readable account of how the buildpack chose and
+// installed Composer.
+type BuildReport struct {
+	Candidates []ReportCandidate `json:"candidates"`
+	Selected   ReportSelection   `json:"selected"`
+	Layers     []ReportLayer     `json:"layers"`
+}
+
+// ReportCandidate is a version constraint requested for Composer and where
+// it came from.
+type ReportCandidate struct {
+	Source     string `json:"source"`
+	Constraint string `json:"constraint"`
+}
+
+// ReportSelection is the Composer version that was selected for the primary
+// installation.
+type ReportSelection struct {
+	Version  string `json:"version"`
+	Source   string `json:"source"`
+	Checksum string `json:"checksum"`
+}
+
+// ReportLayer records what happened to one Composer layer during the build.
+// Decision is one of "installed", "reinstalled" or "reused".
+type ReportLayer struct {
+	Name                string `json:"name"`
+	Command             string `json:"command"`
+	Version             string `json:"version"`
+	Checksum            string `json:"checksum"`
+	Decision            string `json:"decision"`
+	InstallDurationMS   int64  `json:"install_duration_ms"`
+	SBOMDurationMS      int64  `json:"sbom_duration_ms"`
+	SmokeTestDurationMS int64  `json:"smoke_test_duration_ms"`
+}
+
+// layerReport is the copy of the report written into the Composer layer. It
+// leaves out the decisions and durations, which differ between otherwise
+// identical builds and would change the layer digest.
+type layerReport struct {
+	Candidates []ReportCandidate     `json:"candidates"`
+	Selected   ReportSelection       `json:"selected"`
+	Layers     []layerReportInstance `json:"layers"`
+}
+
+type layerReportInstance struct {
+	Name     string `json:"name"`
+	Command  string `json:"command"`
+	Version  string `json:"version"`
+	Checksum string `json:"checksum"`
+}
+
+func (r BuildReport) reproducible() layerReport {
+	layers := []layerReportInstance{}
+	for _, layer := range r.Layers {
+		layers = append(layers, layerReportInstance{
+			Name:     layer.Name,
+			Command:  layer.Command,
+			Version:  layer.Version,
+			Checksum: layer.Checksum,
+		})
+	}
+
+	return layerReport{
+		Candidates: r.Candidates,
+		Selected:   r.Selected,
+		Layers:     layers,
+	}
+}
+
+// reportCandidates lists the version sources of the given entries in the same
+// way as scribe.Emitter.Candidates logs them.
+func reportCandidates(entries []packit.BuildpackPlanEntry) []ReportCandidate {
+	candidates := []ReportCandidate{}
+	seen := map[ReportCandidate]bool{}
+	for _, entry := range entries {
+		source, ok := entry.Metadata["version-source"].(string)
+		if !ok {
+			source = "<unknown>"
+		}
+
+		constraint, _ := entry.Metadata["version"].(string)
+
+		candidate := ReportCandidate{Source: source, Constraint: constraint}
+		if seen[candidate] {
+			continue
+		}
+		seen[candidate] = true
+
+		candidates = append(candidates, candidate)
+	}
+
+	return candidates
+}
+
+// writeReport writes the report as indented JSON to the given path.
+func writeReport(path string, report interface{}) error {
+	content, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, append(content, '\n'), 0644)
+}
@@ -1,31 +1,33 @@
 package main
 
 import (
+	"fmt"
 	"os"
 
 	"github.com/paketo-buildpacks/composer"
 	"github.com/paketo-buildpacks/packit/v2"
 	"github.com/paketo-buildpacks/packit/v2/cargo"
+	"github.com/paketo-buildpacks/packit/v2/pexec"
 	"github.com/paketo-buildpacks/packit/v2/postal"
-	"github.com/paketo-buildpacks/packit/v2/sbom"
-	"github.com/paketo-buildpacks/packit/v2/scribe"
+	"github.com/paketo-buildpacks/packit/v2/servicebindings"
 )
 
-type Generator struct{}
-
-func (f Generator) GenerateFromDependency(dependency postal.Dependency, path string) (sbom.SBOM, error) {
-	return sbom.GenerateFromDependency(dependency, path)
-}
-
 func main() {
-	logEmitter := scribe.NewEmitter(os.Stdout).WithLevel(os.Getenv("BP_LOG_LEVEL"))
+	logger, err := composer.NewLogger(os.Stdout, os.Getenv("BP_LOG_FORMAT"), os.Getenv("BP_LOG_LEVEL"))
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
 	dependencyManager := postal.NewService(cargo.NewTransport())
 
 	packit.Run(
-		composer.Detect(),
+		composer.Detect(logger),
 		composer.Build(
-			logEmitter,
+			logger,
 			dependencyManager,
-			Generator{}),
+			composer.NewPharSBOMGenerator(),
+			servicebindings.NewResolver(),
+			pexec.NewExecutable("env")),
 	)
 }
@@ -0,0 +1,143 @@
+package composer
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/anchore/syft/syft/artifact"
+	"github.com/anchore/syft/syft/cpe"
+	"github.com/anchore/syft/syft/pkg"
+	syftsbom "github.com/anchore/syft/syft/sbom"
+	"github.com/anchore/syft/syft/source"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+)
+
+// PharSBOMGenerator generates the SBOM for the Composer dependency. Next to
+// the composer package itself it lists every library bundled inside
+// composer.phar, each related to composer by a "contains" relationship, so
+// that scanners can match vulnerabilities in those libraries.
+type PharSBOMGenerator struct{}
+
+// ErrBundledPackages is returned when the libraries bundled in composer.phar
+// cannot be read.
+var ErrBundledPackages = errors.New("failed to read bundled packages")
+
+func NewPharSBOMGenerator() PharSBOMGenerator {
+	return PharSBOMGenerator{}
+}
+
+// GenerateFromDependency returns the SBOM for the given dependency installed
+// into dir. The libraries are read from dir/libexec/composer.phar; when it
+// does not exist the SBOM only contains the dependency itself.
+func (g PharSBOMGenerator) GenerateFromDependency(dependency postal.Dependency, dir string) (sbom.SBOM, error) {
+	composerPackage, err := dependencyPackage(dependency)
+	if err != nil {
+		return sbom.SBOM{}, err
+	}
+	composerPackage.SetID()
+
+	packages := []pkg.Package{composerPackage}
+	var relationships []artifact.Relationship
+
+	pharPath := filepath.Join(dir, "libexec", PharFilename)
+	bundled, err := ReadBundledPackages(pharPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return sbom.SBOM{}, fmt.Errorf("%w from %s: %w", ErrBundledPackages, pharPath, err)
+	}
+
+	for _, b := range bundled {
+		bundledPackage := composerLibraryPackage(b)
+		bundledPackage.SetID()
+
+		packages = append(packages, bundledPackage)
+		relationships = append(relationships, artifact.Relationship{
+			From: composerPackage,
+			To:   bundledPackage,
+			Type: artifact.ContainsRelationship,
+		})
+	}
+
+	return sbom.NewSBOM(syftsbom.SBOM{
+		Artifacts: syftsbom.Artifacts{
+			Packages: pkg.NewCollection(packages...),
+		},
+		Relationships: relationships,
+		Source: source.Description{
+			Metadata: source.DirectoryMetadata{
+				Path: dir,
+			},
+		},
+	}), nil
+}
+
+// dependencyPackage mirrors sbom.GenerateFromDependency so that the composer
+// package is described the same way it always has been.
+func dependencyPackage(dependency postal.Dependency) (pkg.Package, error) {
+	//nolint Ignore SA1019, informed usage of deprecated field
+	cpeStrings := dependency.CPEs
+	if len(cpeStrings) == 0 {
+		//nolint Ignore SA1019, informed usage of deprecated field
+		cpeString := dependency.CPE
+		if cpeString == "" {
+			cpeString = sbom.UnknownCPE
+		}
+		cpeStrings = []string{cpeString}
+	}
+
+	var cpes []cpe.CPE
+	for _, cpeString := range cpeStrings {
+		c, err := cpe.New(cpeString, cpe.DeclaredSource)
+		if err != nil {
+			return pkg.Package{}, err
+		}
+		cpes = append(cpes, c)
+	}
+
+	licenses := pkg.NewLicenseSet()
+	for _, license := range dependency.Licenses {
+		licenses.Add(pkg.NewLicense(license))
+	}
+
+	return pkg.Package{
+		Name:     dependency.Name,
+		Version:  dependency.Version,
+		Licenses: licenses,
+		CPEs:     cpes,
+		PURL:     dependency.PURL,
+	}, nil
+}
+
+func composerLibraryPackage(b BundledPackage) pkg.Package {
+	licenses := pkg.NewLicenseSet()
+	for _, license := range b.Licenses {
+		licenses.Add(pkg.NewLicense(license))
+	}
+
+	var source pkg.PhpComposerExternalReference
+	if b.Reference != "" {
+		source = pkg.PhpComposerExternalReference{
+			Type:      "git",
+			URL:       b.SourceURL,
+			Reference: b.Reference,
+		}
+	}
+
+	return pkg.Package{
+		Name:     b.Name,
+		Version:  b.Version,
+		Type:     pkg.PhpComposerPkg,
+		Language: pkg.PHP,
+		Licenses: licenses,
+		PURL:     fmt.Sprintf("pkg:composer/%s@%s", b.Name, b.Version),
+		Metadata: pkg.PhpComposerInstalledEntry{
+			Name:    b.Name,
+			Version: b.Version,
+			Type:    b.Type,
+			License: b.Licenses,
+			Source:  source,
+		},
+	}
+}
@@ -0,0 +1,335 @@
+package composer_test
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	. "github.com/onsi/gomega"
+	"github.com/paketo-buildpacks/composer"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+	"github.com/paketo-buildpacks/packit/v2/sbom"
+	"github.com/sclevine/spec"
+)
+
+const installedPHP = `<?php return array(
+    'root' => array(
+        'name' => 'composer/composer',
+        'pretty_version' => '2.4.4',
+        'version' => '2.4.4.0',
+        'reference' => 'e8d9087229bcdbc5867594d3098091412f1130cf',
+        'type' => 'library',
+        'install_path' => __DIR__ . '/../../',
+        'aliases' => array(),
+        'dev' => false,
+    ),
+    'versions' => array(
+        'composer/composer' => array(
+            'pretty_version' => '2.4.4',
+            'version' => '2.4.4.0',
+            'reference' => 'e8d9087229bcdbc5867594d3098091412f1130cf',
+            'type' => 'library',
+            'install_path' => __DIR__ . '/../../',
+            'aliases' => array(),
+            'dev_requirement' => false,
+        ),
+        'psr/log-implementation' => array(
+            'dev_requirement' => false,
+            'provided' => array(
+                0 => '1.0|2.0',
+            ),
+        ),
+        'symfony/console' => array(
+            'pretty_version' => 'v5.4.14',
+            'version' => '5.4.14.0',
+            'reference' => '984ea2c0f45f42dfed01d2f3987b187467c4b16d',
+            'type' => 'library',
+            'install_path' => __DIR__ . '/../symfony/console',
+            'aliases' => array(),
+            'dev_requirement' => false,
+        ),
+        'composer/ca-bundle' => array(
+            'pretty_version' => '1.3.4',
+            'version' => '1.3.4.0',
+            'reference' => '69098eca243998b53eed7a48d82dedd28b447cd5',
+            'type' => 'library',
+            'install_path' => __DIR__ . '/./ca-bundle',
+            'aliases' => array(),
+            'dev_requirement' => false,
+        ),
+    ),
+);
+`
+
+type pharFile struct {
+	name     string
+	content  string
+	compress bool
+}
+
+// writePhar writes a minimal archive in the phar file format containing the
+// given files.
+func writePhar(t *testing.T, path string, files ...pharFile) {
+	t.Helper()
+
+	var manifest, data bytes.Buffer
+	write := func(buffer *bytes.Buffer, value interface{}) {
+		if err := binary.Write(buffer, binary.LittleEndian, value); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	write(&manifest, uint32(len(files)))
+	write(&manifest, uint16(0x1100))
+	write(&manifest, uint32(0x00010000))
+	write(&manifest, uint32(len("composer.phar")))
+	manifest.WriteString("composer.phar")
+	write(&manifest, uint32(0))
+
+	for _, file := range files {
+		content := []byte(file.content)
+		flags := uint32(0644)
+		if file.compress {
+			var compressed bytes.Buffer
+			writer, err := flate.NewWriter(&compressed, flate.DefaultCompression)
+			if err != nil {
+				t.Fatal(err)
+			}
+			_, _ = writer.Write(content)
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+			content = compressed.Bytes()
+			flags |= 0x1000
+		}
+
+		write(&manifest, uint32(len(file.name)))
+		manifest.WriteString(file.name)
+		write(&manifest, uint32(len(file.content)))
+		write(&manifest, uint32(0))
+		write(&manifest, uint32(len(content)))
+		write(&manifest, uint32(0))
+		write(&manifest, flags)
+		write(&manifest, uint32(0))
+
+		data.Write(content)
+	}
+
+	var phar bytes.Buffer
+	phar.WriteString("#!/usr/bin/env php\n<?php\nPhar::mapPhar('composer.phar');\n__HALT_COMPILER(); ?>\r\n")
+	write(&phar, uint32(manifest.Len()))
+	phar.Write(manifest.Bytes())
+	phar.Write(data.Bytes())
+
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, phar.Bytes(), 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func testSBOMGenerator(t *testing.T, context spec.G, it spec.S) {
+	var (
+		Expect = NewWithT(t).Expect
+
+		layerDir   string
+		dependency postal.Dependency
+		generator  composer.PharSBOMGenerator
+	)
+
+	it.Before(func() {
+		layerDir = t.TempDir()
+
+		dependency = postal.Dependency{
+			ID:       "composer",
+			Name:     "composer",
+			Version:  "2.4.4",
+			CPE:      "cpe:2.3:a:getcomposer:composer:2.4.4:*:*:*:*:*:*:*",
+			PURL:     "pkg:generic/composer@2.4.4",
+			Licenses: []string{"MIT"},
+		}
+
+		generator = composer.NewPharSBOMGenerator()
+	})
+
+	formatSBOM := func(bom sbom.SBOM, mediaType string) string {
+		formatter, err := bom.InFormats(mediaType)
+		Expect(err).NotTo(HaveOccurred())
+
+		formats := formatter.Formats()
+		Expect(formats).To(HaveLen(1))
+
+		var buffer bytes.Buffer
+		_, err = buffer.ReadFrom(formats[0].Content)
+		Expect(err).NotTo(HaveOccurred())
+
+		return buffer.String()
+	}
+
+	context("when the phar bundles libraries", func() {
+		it.Before(func() {
+			writePhar(t, filepath.Join(layerDir, "libexec", "composer.phar"),
+				pharFile{name: "bin/composer", content: "<?php // bin"},
+				pharFile{name: "vendor/composer/installed.php", content: installedPHP, compress: true},
+			)
+		})
+
+		it("lists them as packages contained in composer", func() {
+			bom, err := generator.GenerateFromDependency(dependency, layerDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			cycloneDX := formatSBOM(bom, sbom.CycloneDXFormat)
+			Expect(cycloneDX).To(ContainSubstring(`"purl": "pkg:generic/composer@2.4.4"`))
+			Expect(cycloneDX).To(ContainSubstring(`"purl": "pkg:composer/symfony/console@v5.4.14"`))
+			Expect(cycloneDX).To(ContainSubstring(`"purl": "pkg:composer/composer/ca-bundle@1.3.4"`))
+			Expect(cycloneDX).NotTo(ContainSubstring("psr/log-implementation"))
+			Expect(cycloneDX).NotTo(ContainSubstring("pkg:composer/composer/composer"))
+
+			syft := formatSBOM(bom, sbom.SyftFormat)
+			Expect(syft).To(ContainSubstring(`"type":"contains"`))
+			Expect(syft).To(ContainSubstring(`"language":"php"`))
+			Expect(syft).To(ContainSubstring(`"reference":"984ea2c0f45f42dfed01d2f3987b187467c4b16d"`))
+		})
+	})
+
+	context("when the phar contains an installed.json", func() {
+		it.Before(func() {
+			writePhar(t, filepath.Join(layerDir, "libexec", "composer.phar"),
+				pharFile{name: "vendor/composer/installed.php", content: installedPHP},
+				pharFile{name: "vendor/composer/installed.json", content: `{
+					"packages": [
+						{
+							"name": "seld/jsonlint",
+							"version": "1.9.0",
+							"version_normalized": "1.9.0.0",
+							"type": "library",
+							"license": ["MIT"],
+							"source": {
+								"type": "git",
+								"url": "https://github.com/Seldaek/jsonlint.git",
+								"reference": "4211420d25eba80712bff236a98960ef68b866b7"
+							}
+						}
+					]
+				}`},
+			)
+		})
+
+		it("prefers it over installed.php", func() {
+			bom, err := generator.GenerateFromDependency(dependency, layerDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			cycloneDX := formatSBOM(bom, sbom.CycloneDXFormat)
+			Expect(cycloneDX).To(ContainSubstring(`"purl": "pkg:composer/seld/jsonlint@1.9.0"`))
+			Expect(cycloneDX).NotTo(ContainSubstring("symfony/console"))
+
+			syft := formatSBOM(bom, sbom.SyftFormat)
+			Expect(syft).To(ContainSubstring(`"url":"https://github.com/Seldaek/jsonlint.git"`))
+		})
+	})
+
+	context("when the phar does not exist", func() {
+		it("only lists composer", func() {
+			bom, err := generator.GenerateFromDependency(dependency, layerDir)
+			Expect(err).NotTo(HaveOccurred())
+
+			cycloneDX := formatSBOM(bom, sbom.CycloneDXFormat)
+			Expect(cycloneDX).To(ContainSubstring(`"purl": "pkg:generic/composer@2.4.4"`))
+			Expect(cycloneDX).NotTo(ContainSubstring("pkg:composer/"))
+		})
+	})
+
+	context("failure cases", func() {
+		context("when the file is not a phar", func() {
+			it.Before(func() {
+				Expect(os.MkdirAll(filepath.Join(layerDir, "libexec"), os.ModePerm)).To(Succeed())
+				Expect(os.WriteFile(filepath.Join(layerDir, "libexec", "composer.phar"), []byte("not a phar"), 0755)).To(Succeed())
+			})
+
+			it("returns an error", func() {
+				_, err := generator.GenerateFromDependency(dependency, layerDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to read bundled packages")))
+				Expect(err).To(MatchError(ContainSubstring("missing __HALT_COMPILER();")))
+			})
+		})
+
+		context("when the phar has no installation record", func() {
+			it.Before(func() {
+				writePhar(t, filepath.Join(layerDir, "libexec", "composer.phar"),
+					pharFile{name: "bin/composer", content: "<?php // bin"},
+				)
+			})
+
+			it("returns an error", func() {
+				_, err := generator.GenerateFromDependency(dependency, layerDir)
+				Expect(err).To(MatchError(composer.ErrPharEntryNotFound))
+			})
+		})
+
+		context("when the installation record is malformed", func() {
+			it.Before(func() {
+				writePhar(t, filepath.Join(layerDir, "libexec", "composer.phar"),
+					pharFile{name: "vendor/composer/installed.php", content: "<?php return array('versions' => "},
+				)
+			})
+
+			it("returns an error", func() {
+				_, err := generator.GenerateFromDependency(dependency, layerDir)
+				Expect(err).To(MatchError(ContainSubstring("failed to parse vendor/composer/installed.php")))
+			})
+		})
+	})
+}
+
+func testBundledPackages(t *testing.T, context spec.G, it spec.S) {
+	var Expect = NewWithT(t).Expect
+
+	context("ParseInstalledPHP", func() {
+		it("returns the installed packages sorted by name", func() {
+			packages, err := composer.ParseInstalledPHP([]byte(installedPHP))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(packages).To(Equal([]composer.BundledPackage{
+				{
+					Name:      "composer/ca-bundle",
+					Version:   "1.3.4",
+					Type:      "library",
+					Reference: "69098eca243998b53eed7a48d82dedd28b447cd5",
+				},
+				{
+					Name:      "symfony/console",
+					Version:   "v5.4.14",
+					Type:      "library",
+					Reference: "984ea2c0f45f42dfed01d2f3987b187467c4b16d",
+				},
+			}))
+		})
+	})
+
+	context("ParseInstalledJSON", func() {
+		it("supports the Composer 1 layout", func() {
+			packages, err := composer.ParseInstalledJSON([]byte(`[
+				{"name": "psr/log", "version": "1.1.4", "license": ["MIT"]},
+				{"name": "psr/container", "version": "1.1.1"}
+			]`))
+			Expect(err).NotTo(HaveOccurred())
+
+			var names []string
+			for _, p := range packages {
+				names = append(names, p.Name+"@"+p.Version)
+			}
+			sort.Strings(names)
+			Expect(names).To(Equal([]string{"psr/container@1.1.1", "psr/log@1.1.4"}))
+			Expect(packages[0].Licenses).To(Equal([]string{"MIT"}))
+		})
+
+		it("returns an error when the content is not JSON", func() {
+			_, err := composer.ParseInstalledJSON([]byte("%%%"))
+			Expect(err).To(MatchError(ContainSubstring("failed to parse vendor/composer/installed.json")))
+		})
+	})
+}
@@ -135,6 +135,23 @@ function buildpack::archive() {
       --version "${version}" \
       --output "${BUILD_DIR}/buildpack.tgz"
   fi
+
+  provenance::add
+}
+
+# jam only packages the individual files listed in include-files, so the
+# provenance statements written by the dependency update are added here.
+function provenance::add() {
+  if ! compgen -G "${ROOT_DIR}/provenance/*.intoto.json" > /dev/null; then
+    return
+  fi
+
+  util::print::info "Adding provenance statements to ${BUILD_DIR}/buildpack.tgz..."
+
+  gzip --decompress --stdout "${BUILD_DIR}/buildpack.tgz" > "${BUILD_DIR}/buildpack.tar"
+  tar --append --file "${BUILD_DIR}/buildpack.tar" --directory "${ROOT_DIR}" provenance
+  gzip --stdout "${BUILD_DIR}/buildpack.tar" > "${BUILD_DIR}/buildpack.tgz"
+  rm "${BUILD_DIR}/buildpack.tar"
 }
 
 function buildpackage::create() {
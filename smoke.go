@@ -0,0 +1,68 @@
+package composer
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2/pexec"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+)
+
+var reportedVersionPattern = regexp.MustCompile(`(?m)^Composer (?:version )?(\S+)`)
+
+// smokeTest runs the installed command the way later buildpacks do: by its
+// name, through the wrapper script in binPath, with binPath in front of the
+// PATH of the build. It checks that the command runs and reports the version
+// of the dependency, so that a broken wrapper fails the build. It does
+// nothing when there is no PHP on the PATH, as Composer does not need it to
+// be installed.
+func smokeTest(logger Logger, env Executable, binPath, command string, dependency postal.Dependency) error {
+	if _, err := exec.LookPath("php"); err != nil {
+		logger.DebugSubprocess("Skipping smoke test: PHP is not on the PATH")
+		logger.DebugBreak()
+		return nil
+	}
+
+	buffer := bytes.NewBuffer(nil)
+	err := env.Execute(pexec.Execution{
+		Args: []string{command, "--version", "--no-ansi", "--no-interaction"},
+		Env: append(os.Environ(),
+			fmt.Sprintf("PATH=%s%c%s", binPath, os.PathListSeparator, os.Getenv("PATH")),
+			"COMPOSER_ALLOW_SUPERUSER=1",
+			"COMPOSER_DISABLE_NETWORK=1",
+			"XDEBUG_MODE=off",
+		),
+		Stdout: buffer,
+		Stderr: buffer,
+	})
+
+	output := strings.TrimSpace(buffer.String())
+
+	logger.DebugSubprocess("Output of composer --version:")
+	for _, line := range strings.Split(output, "\n") {
+		logger.DebugAction("%s", line)
+	}
+	logger.DebugBreak()
+
+	if err != nil {
+		return fmt.Errorf("failed to run Composer %s as %s: %w\n%s", dependency.Version, command, err, output)
+	}
+
+	matches := reportedVersionPattern.FindStringSubmatch(output)
+	if matches == nil {
+		return fmt.Errorf("failed to read the version of Composer %s from the output of composer --version:\n%s", dependency.Version, output)
+	}
+
+	if dependency.Version != CustomPharVersion && matches[1] != dependency.Version {
+		return fmt.Errorf("installed Composer %s but composer --version reports %s", dependency.Version, matches[1])
+	}
+
+	logger.Subprocess("Verified that Composer %s runs", matches[1])
+	logger.Break()
+
+	return nil
+}
@@ -0,0 +1,142 @@
+package composer
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/paketo-buildpacks/packit/v2"
+	"github.com/paketo-buildpacks/packit/v2/postal"
+)
+
+// The values accepted by BP_COMPOSER_VERSION_RESOLUTION.
+const (
+	// VersionResolutionPriority installs the version requested by the entry
+	// with the highest priority and ignores the others.
+	VersionResolutionPriority = "priority"
+
+	// VersionResolutionIntersection installs the highest version that
+	// satisfies the constraints of every entry.
+	VersionResolutionIntersection = "intersection"
+)
+
+type versionRequirement struct {
+	source     string
+	constraint string
+}
+
+// versionResolutionMode returns the value of BP_COMPOSER_VERSION_RESOLUTION,
+// defaulting to VersionResolutionPriority.
+func versionResolutionMode() (string, error) {
+	mode, ok := os.LookupEnv("BP_COMPOSER_VERSION_RESOLUTION")
+	if !ok || mode == "" {
+		return VersionResolutionPriority, nil
+	}
+
+	mode = strings.ToLower(mode)
+	switch mode {
+	case VersionResolutionPriority, VersionResolutionIntersection:
+		return mode, nil
+	default:
+		return "", fmt.Errorf("invalid value for BP_COMPOSER_VERSION_RESOLUTION %q: must be one of %q or %q", mode, VersionResolutionPriority, VersionResolutionIntersection)
+	}
+}
+
+// resolveIntersection resolves the highest dependency that satisfies the
+// version constraints of all given entries. It returns a synthesized entry
+// describing the combined constraint and its sources, for logging. When no
+// dependency satisfies every constraint, the error names the sources whose
+// constraints conflict.
+func resolveIntersection(dependencyManager DependencyManager, path, stack string, entries []packit.BuildpackPlanEntry) (packit.BuildpackPlanEntry, postal.Dependency, error) {
+	var requirements []versionRequirement
+	for _, entry := range entries {
+		constraint, _ := entry.Metadata["version"].(string)
+		if constraint == "" || constraint == "default" {
+			continue
+		}
+
+		source, _ := entry.Metadata["version-source"].(string)
+		if source == "" {
+			source = "<unknown>"
+		}
+
+		requirements = append(requirements, versionRequirement{source: source, constraint: constraint})
+	}
+
+	var sources, constraints []string
+	for _, requirement := range requirements {
+		sources = append(sources, requirement.source)
+		constraints = append(constraints, requirement.constraint)
+	}
+
+	entry := packit.BuildpackPlanEntry{
+		Name: "composer",
+		Metadata: map[string]interface{}{
+			"version":        intersectConstraints(constraints...),
+			"version-source": fmt.Sprintf("intersection of %s", strings.Join(sources, ", ")),
+		},
+	}
+	if len(requirements) == 0 {
+		entry.Metadata["version-source"] = ""
+	}
+
+	dependency, err := dependencyManager.Resolve(path, "composer", entry.Metadata["version"].(string), stack)
+	if err == nil {
+		return entry, dependency, nil
+	}
+
+	var conflicts []string
+	var satisfiable []versionRequirement
+	for _, requirement := range requirements {
+		_, singleErr := dependencyManager.Resolve(path, "composer", requirement.constraint, stack)
+		if singleErr != nil {
+			conflicts = append(conflicts, fmt.Sprintf("%s requires %q, which no available version satisfies", requirement.source, requirement.constraint))
+			continue
+		}
+		satisfiable = append(satisfiable, requirement)
+	}
+
+	for i := 0; i < len(satisfiable); i++ {
+		for j := i + 1; j < len(satisfiable); j++ {
+			constraint := intersectConstraints(satisfiable[i].constraint, satisfiable[j].constraint)
+			_, pairErr := dependencyManager.Resolve(path, "composer", constraint, stack)
+			if pairErr != nil {
+				conflicts = append(conflicts, fmt.Sprintf("%s requires %q but %s requires %q",
+					satisfiable[i].source, satisfiable[i].constraint,
+					satisfiable[j].source, satisfiable[j].constraint))
+			}
+		}
+	}
+
+	if len(conflicts) == 0 {
+		var described []string
+		for _, requirement := range requirements {
+			described = append(described, fmt.Sprintf("%s requires %q", requirement.source, requirement.constraint))
+		}
+		conflicts = []string{strings.Join(described, ", ")}
+	}
+
+	return packit.BuildpackPlanEntry{}, postal.Dependency{}, fmt.Errorf("no Composer version satisfies all requirements: %s: %w", strings.Join(conflicts, "; "), err)
+}
+
+// intersectConstraints combines semver constraints into one that only
+// matches versions matching all of them. Alternatives separated by "||" are
+// distributed over each other, because "," binds more tightly than "||".
+func intersectConstraints(constraints ...string) string {
+	intersection := []string{""}
+	for _, constraint := range constraints {
+		var combined []string
+		for _, prefix := range intersection {
+			for _, alternative := range strings.Split(constraint, "||") {
+				alternative = strings.TrimSpace(alternative)
+				if prefix != "" {
+					alternative = fmt.Sprintf("%s, %s", prefix, alternative)
+				}
+				combined = append(combined, alternative)
+			}
+		}
+		intersection = combined
+	}
+
+	return strings.Join(intersection, " || ")
+}
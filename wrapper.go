@@ -0,0 +1,114 @@
+package composer
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// PharFilename is the name of the phar inside the libexec directory of a
+// Composer layer. The bin directory holds the wrapper script that runs it.
+const PharFilename = "composer.phar"
+
+// phpDistPHPPath is where the php-dist buildpack installs PHP, relative to
+// the layers directory.
+const phpDistPHPPath = "paketo-buildpacks_php-dist/php/bin/php"
+
+var memoryLimitPattern = regexp.MustCompile(`^(-1|[0-9]+[KMGkmg]?)$`)
+
+// wrapperScript runs the phar next to it with the PHP binary it finds first
+// on the PATH, next to $PHPRC or where php-dist installs it. Settings given
+// at build time are only defaults: the same variables set at run time win.
+const wrapperScript = `#!/usr/bin/env bash
+set -e
+
+phar="$(cd "$(dirname "${BASH_SOURCE[0]}")/../libexec" && pwd)/composer.phar"
+
+php_binary="$(command -v php || true)"
+if [[ -z "${php_binary}" && -n "${PHPRC:-}" && -x "${PHPRC}/../bin/php" ]]; then
+  php_binary="${PHPRC}/../bin/php"
+fi
+if [[ -z "${php_binary}" && -x "{{PHP_DIST_PATH}}" ]]; then
+  php_binary="{{PHP_DIST_PATH}}"
+fi
+
+if [[ -z "${php_binary}" ]]; then
+  echo "composer: could not find a PHP binary to run Composer with." >&2
+  echo "composer: looked on the PATH, next to \$PHPRC and in {{PHP_DIST_PATH}}." >&2
+  echo "composer: add a buildpack that provides PHP, such as paketo-buildpacks/php-dist, to the build." >&2
+  exit 127
+fi
+
+{{MEMORY_LIMIT}}
+php_args=()
+if [[ "${COMPOSER_DISABLE_XDEBUG:-{{DISABLE_XDEBUG}}}" == "true" ]]; then
+  export XDEBUG_MODE=off
+  php_args+=(-d xdebug.mode=off)
+fi
+
+exec "${php_binary}" "${php_args[@]}" "${phar}" "$@"
+`
+
+// wrapperSettings are the build-time defaults baked into the wrapper script.
+type wrapperSettings struct {
+	MemoryLimit   string
+	DisableXdebug bool
+	PHPDistPath   string
+}
+
+// readWrapperSettings reads BP_COMPOSER_MEMORY_LIMIT and
+// BP_COMPOSER_DISABLE_XDEBUG. The layers directory is the same at build and
+// launch time, so the wrapper looks for php-dist's PHP inside it.
+func readWrapperSettings(layersPath string) (wrapperSettings, error) {
+	settings := wrapperSettings{
+		DisableXdebug: true,
+		PHPDistPath:   filepath.Join(layersPath, phpDistPHPPath),
+	}
+
+	if value, ok := os.LookupEnv("BP_COMPOSER_MEMORY_LIMIT"); ok && value != "" {
+		if !memoryLimitPattern.MatchString(value) {
+			return wrapperSettings{}, fmt.Errorf("invalid value for BP_COMPOSER_MEMORY_LIMIT %q: must be -1 or a number of bytes with an optional K, M or G suffix", value)
+		}
+		settings.MemoryLimit = value
+	}
+
+	if value, ok := os.LookupEnv("BP_COMPOSER_DISABLE_XDEBUG"); ok && value != "" {
+		disable, err := strconv.ParseBool(value)
+		if err != nil {
+			return wrapperSettings{}, fmt.Errorf("invalid value for BP_COMPOSER_DISABLE_XDEBUG %q: must be true or false", value)
+		}
+		settings.DisableXdebug = disable
+	}
+
+	return settings, nil
+}
+
+// writeWrapper writes the wrapper script for the phar in the layer's libexec
+// directory to path.
+func writeWrapper(path string, settings wrapperSettings) error {
+	memoryLimit := ""
+	if settings.MemoryLimit != "" {
+		memoryLimit = fmt.Sprintf("export COMPOSER_MEMORY_LIMIT=\"${COMPOSER_MEMORY_LIMIT:-%s}\"", settings.MemoryLimit)
+	}
+
+	script := strings.NewReplacer(
+		"{{PHP_DIST_PATH}}", settings.PHPDistPath,
+		"{{MEMORY_LIMIT}}", memoryLimit,
+		"{{DISABLE_XDEBUG}}", strconv.FormatBool(settings.DisableXdebug),
+	).Replace(wrapperScript)
+
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return err
+	}
+
+	err = os.RemoveAll(path)
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, []byte(script), 0755)
+}